@@ -0,0 +1,354 @@
+package hops
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Comparator tells how the value of an alert rule's metric is compared
+// against its threshold
+type Comparator string
+
+// Comparators supported by alert rules
+const (
+	Above        Comparator = ">"
+	AboveOrEqual Comparator = ">="
+	Below        Comparator = "<"
+	BelowOrEqual Comparator = "<="
+)
+
+// holds reports whether v compared against threshold satisfies the
+// comparator
+func (c Comparator) holds(v, threshold float64) bool {
+	switch c {
+	case Above:
+		return v > threshold
+	case AboveOrEqual:
+		return v >= threshold
+	case Below:
+		return v < threshold
+	case BelowOrEqual:
+		return v <= threshold
+	}
+	return false
+}
+
+// valid reports whether the comparator is one of the supported ones
+func (c Comparator) valid() bool {
+	switch c {
+	case Above, AboveOrEqual, Below, BelowOrEqual:
+		return true
+	}
+	return false
+}
+
+// Severity of an alert, e.g. to route critical alerts to a pager
+type Severity string
+
+// Common severities. Any other value may be used as well.
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+// AlertRule describes a condition on the counters of a registry that should
+// alert someone
+type AlertRule struct {
+	// Name of the rule, unique within an Alerter
+	Name string
+
+	// Query expression evaluated against the registry, as with
+	// Registry.Query, e.g. "http.errors" or "http.errors / http.requests"
+	Metric string
+
+	// How the value of Metric is compared against Threshold
+	Comparator Comparator
+
+	Threshold float64
+
+	// How long the condition must hold before the alert fires, to ignore
+	// short spikes. Zero fires on the first evaluation that holds.
+	For time.Duration
+
+	Severity Severity
+}
+
+// AlertState is the state of an alert rule
+type AlertState string
+
+// States of an alert rule. Notifiers only see firing and resolved alerts.
+const (
+	// The condition doesn't hold
+	AlertInactive AlertState = "inactive"
+
+	// The condition holds, but not for long enough to fire
+	AlertPending AlertState = "pending"
+
+	AlertFiring AlertState = "firing"
+
+	// The condition stopped holding after the alert fired
+	AlertResolved AlertState = "resolved"
+)
+
+// Alert is a change of state of an alert rule
+type Alert struct {
+	// Name of the rule
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+
+	State AlertState `json:"state"`
+
+	// Value of the rule's metric when the state changed
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+
+	// When the condition started holding
+	Since time.Time `json:"since"`
+
+	// When the state changed
+	Time time.Time `json:"time"`
+
+	// Set for firing alerts matched by an active silence, which aren't
+	// notified
+	Silenced bool `json:"silenced,omitempty"`
+}
+
+// Notifier is told when alerts fire and resolve, e.g. Webhook
+type Notifier interface {
+	Notify(a Alert)
+}
+
+// NotifierFunc is a function used as a Notifier
+type NotifierFunc func(a Alert)
+
+// Notify calls f(a)
+func (f NotifierFunc) Notify(a Alert) {
+	f(a)
+}
+
+// Alerter evaluates alert rules against the counters of a registry, tracks
+// which of them are firing, and dispatches their changes of state to
+// notifiers. Silences suppress the notifications, but not the evaluation.
+//
+// For example, this pages someone when more than 5% of the requests of the
+// last few minutes failed, for 2 minutes in a row:
+//
+//	a := hops.NewAlerter(r, hops.NewWebhook("https://alerts.example.com/hops"))
+//	a.AddRule(hops.AlertRule{
+//		Name:       "http.error_ratio",
+//		Metric:     "http.errors / http.requests",
+//		Comparator: hops.Above,
+//		Threshold:  0.05,
+//		For:        2 * time.Minute,
+//		Severity:   hops.SeverityCritical,
+//	})
+//	go a.Run(ctx, time.Minute)
+//
+// It's safe to use the alerter concurrently.
+type Alerter struct {
+	r *Registry
+
+	// Guards rules, notifiers and silences
+	mu sync.Mutex
+
+	// Rules ordered by name
+	rules []*ruleState
+
+	notifiers []Notifier
+
+	// Silences that haven't ended yet, by the time of the latest
+	// evaluation
+	silences []*Silence
+}
+
+// ruleState tracks the state of an alert rule between evaluations
+type ruleState struct {
+	rule  AlertRule
+	state AlertState
+
+	// When the condition started holding, while pending or firing
+	since time.Time
+
+	// When the rule last fired
+	fired time.Time
+
+	// Latest value that satisfied the condition
+	value float64
+
+	// Set while the rule fires and its notifiers were told so, i.e. it
+	// wasn't silenced
+	notified bool
+
+	// Set when an active silence matched the rule in the latest evaluation
+	silenced bool
+}
+
+// NewAlerter creates an alerter for the counters of r, which dispatches
+// changes of state to the given notifiers
+func NewAlerter(r *Registry, notifiers ...Notifier) *Alerter {
+	return &Alerter{r: r, notifiers: notifiers}
+}
+
+// AddNotifier adds a notifier for the changes of state that happen from now on
+func (a *Alerter) AddNotifier(n Notifier) {
+	a.mu.Lock()
+	a.notifiers = append(a.notifiers, n)
+	a.mu.Unlock()
+}
+
+// AddRule adds an alert rule, which is evaluated from the next evaluation
+// on. It fails with ErrInvalidConfig if the rule has no name, metric or
+// known comparator, and with ErrAlreadyRegistered if its name is taken.
+func (a *Alerter) AddRule(rule AlertRule) error {
+	switch {
+	case rule.Name == "" || rule.Metric == "":
+		return fmt.Errorf("%w: alert rules need a name and a metric", ErrInvalidConfig)
+	case !rule.Comparator.valid():
+		return fmt.Errorf("%w: alert rule %q has unknown comparator %q", ErrInvalidConfig, rule.Name, rule.Comparator)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	i := sort.Search(len(a.rules), func(i int) bool { return a.rules[i].rule.Name >= rule.Name })
+	if i < len(a.rules) && a.rules[i].rule.Name == rule.Name {
+		return fmt.Errorf("%w: alert rule %q", ErrAlreadyRegistered, rule.Name)
+	}
+	a.rules = append(a.rules, nil)
+	copy(a.rules[i+1:], a.rules[i:])
+	a.rules[i] = &ruleState{rule: rule, state: AlertInactive}
+	return nil
+}
+
+// Run evaluates the rules once every interval, e.g. once every time unit of
+// the counters, until ctx is done
+func (a *Alerter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			a.Evaluate(now)
+		}
+	}
+}
+
+// Evaluate evaluates every rule at the given time instant and notifies the
+// notifiers of the rules that fired or resolved.
+//
+// Rules whose metric can't be evaluated, e.g. because a counter isn't
+// registered or because of a division by zero, keep their state.
+//
+// Silenced rules are notified once their silence ends, if they still fire.
+// Their resolution is only notified if their firing was.
+func (a *Alerter) Evaluate(now time.Time) {
+	a.mu.Lock()
+	a.pruneSilences(now)
+
+	var changes []Alert
+	for _, rs := range a.rules {
+		alert, changed := rs.evaluate(a.r, now)
+		rs.silenced = a.silenced(rs.rule, now)
+
+		switch {
+		case changed && alert.State == AlertResolved:
+			if rs.notified {
+				changes = append(changes, alert)
+			}
+			rs.notified = false
+		case rs.state == AlertFiring && !rs.notified && !rs.silenced:
+			changes = append(changes, rs.alert(AlertFiring, rs.value, rs.fired))
+			rs.notified = true
+		}
+	}
+	notifiers := a.notifiers
+	a.mu.Unlock()
+
+	for _, alert := range changes {
+		for _, n := range notifiers {
+			n.Notify(alert)
+		}
+	}
+}
+
+// Alerts returns the alerts that are currently firing, ordered by rule name,
+// including the silenced ones
+func (a *Alerter) Alerts() []Alert {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var alerts []Alert
+	for _, rs := range a.rules {
+		if rs.state == AlertFiring {
+			alert := rs.alert(AlertFiring, rs.value, rs.fired)
+			alert.Silenced = rs.silenced
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts
+}
+
+// State returns the state of the rule with the given name, or
+// AlertInactive if there is no such rule
+func (a *Alerter) State(rule string) AlertState {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, rs := range a.rules {
+		if rs.rule.Name == rule {
+			return rs.state
+		}
+	}
+	return AlertInactive
+}
+
+// evaluate updates the state of the rule, and returns the alert to notify
+// about if it fired or resolved
+func (rs *ruleState) evaluate(r *Registry, now time.Time) (Alert, bool) {
+	v, err := r.Query(rs.rule.Metric)
+	if err != nil || math.IsNaN(v) {
+		return Alert{}, false
+	}
+
+	if !rs.rule.Comparator.holds(v, rs.rule.Threshold) {
+		fired := rs.state == AlertFiring
+		rs.state = AlertInactive
+		if fired {
+			return rs.alert(AlertResolved, v, now), true
+		}
+		return Alert{}, false
+	}
+
+	rs.value = v
+	if rs.state == AlertInactive {
+		rs.state = AlertPending
+		rs.since = now
+	}
+	if rs.state == AlertPending && now.Sub(rs.since) >= rs.rule.For {
+		rs.state = AlertFiring
+		rs.fired = now
+		return rs.alert(AlertFiring, v, now), true
+	}
+	return Alert{}, false
+}
+
+// alert describes the rule in the given state
+func (rs *ruleState) alert(state AlertState, v float64, now time.Time) Alert {
+	return Alert{
+		Rule:      rs.rule.Name,
+		Severity:  rs.rule.Severity,
+		State:     state,
+		Value:     v,
+		Threshold: rs.rule.Threshold,
+		Since:     rs.since,
+		Time:      now,
+	}
+}
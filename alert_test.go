@@ -0,0 +1,111 @@
+package hops_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestAlerter(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+	c := hops.NewManualCounter(3, time.Minute, start)
+	r := hops.NewRegistry()
+	r.MustRegister("jobs.failed", c)
+
+	var got []hops.Alert
+	a := hops.NewAlerter(r, hops.NotifierFunc(func(alert hops.Alert) { got = append(got, alert) }))
+	err := a.AddRule(hops.AlertRule{
+		Name:       "failures",
+		Metric:     "jobs.failed",
+		Comparator: hops.AboveOrEqual,
+		Threshold:  3,
+		For:        2 * time.Minute,
+		Severity:   hops.SeverityWarning,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+	firing := hops.Alert{
+		Rule: "failures", Severity: hops.SeverityWarning, State: hops.AlertFiring,
+		Value: 3, Threshold: 3, Since: at(1), Time: at(3),
+	}
+	resolved := firing
+	resolved.State, resolved.Value, resolved.Time = hops.AlertResolved, 0, at(7)
+
+	tests := []struct {
+		observe   int
+		minutes   int
+		wantState hops.AlertState
+		wantSent  []hops.Alert
+	}{
+		{1, 0, hops.AlertInactive, nil},
+		{3, 1, hops.AlertPending, nil},
+		// Holds, but not for long enough yet
+		{0, 2, hops.AlertPending, nil},
+		{0, 3, hops.AlertFiring, []hops.Alert{firing}},
+		// Fires only once
+		{3, 4, hops.AlertFiring, []hops.Alert{firing}},
+		{0, 6, hops.AlertFiring, []hops.Alert{firing}},
+		{0, 7, hops.AlertInactive, []hops.Alert{firing, resolved}},
+	}
+
+	for i, tt := range tests {
+		c.Tick(at(tt.minutes))
+		for j := 0; j < tt.observe; j++ {
+			c.Observe()
+		}
+		a.Evaluate(at(tt.minutes))
+
+		if got := a.State("failures"); got != tt.wantState {
+			t.Errorf("step %d: expected: %v, got: %v", i, tt.wantState, got)
+		}
+		if !reflect.DeepEqual(got, tt.wantSent) {
+			t.Errorf("step %d: expected: %v, got: %v", i, tt.wantSent, got)
+		}
+		if firing := a.Alerts(); (tt.wantState == hops.AlertFiring) != (len(firing) == 1) {
+			t.Errorf("step %d: unexpected firing alerts: %v", i, firing)
+		}
+	}
+}
+
+func TestAlerterAddRule(t *testing.T) {
+	valid := hops.AlertRule{Name: "a", Metric: "b", Comparator: hops.Below}
+
+	tests := map[string]struct {
+		rule    hops.AlertRule
+		wantErr error
+	}{
+		"valid":              {hops.AlertRule{Name: "c", Metric: "d", Comparator: hops.Above}, nil},
+		"missing_name":       {hops.AlertRule{Metric: "b", Comparator: hops.Above}, hops.ErrInvalidConfig},
+		"missing_metric":     {hops.AlertRule{Name: "c", Comparator: hops.Above}, hops.ErrInvalidConfig},
+		"unknown_comparator": {hops.AlertRule{Name: "c", Metric: "d", Comparator: "=="}, hops.ErrInvalidConfig},
+		"duplicate_name":     {valid, hops.ErrAlreadyRegistered},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			a := hops.NewAlerter(hops.NewRegistry())
+			if err := a.AddRule(valid); err != nil {
+				t.Fatal(err)
+			}
+			if err := a.AddRule(tt.rule); !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected: %v, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestAlerterUnknownCounter(t *testing.T) {
+	a := hops.NewAlerter(hops.NewRegistry())
+	a.AddRule(hops.AlertRule{Name: "a", Metric: "missing", Comparator: hops.BelowOrEqual})
+
+	a.Evaluate(time.Now())
+	if got := a.State("a"); got != hops.AlertInactive {
+		t.Errorf("expected: %v, got: %v", hops.AlertInactive, got)
+	}
+}
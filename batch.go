@@ -0,0 +1,81 @@
+package hops
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ObserveBatch adds an event to the window at each of the given time
+// instants, e.g. to catch up after reading a backlog of timestamped events.
+// The events are applied under a single lock acquisition, in any order.
+//
+// Events older than the window or later than the current moment in time
+// are dropped. It returns the number of events added.
+func (c *Counter) ObserveBatch(ts []time.Time) int {
+	return c.observeBatch(len(ts), func(i int) (time.Time, uint32) {
+		return ts[i], 1
+	})
+}
+
+// ObserveBuckets is like ObserveBatch, but for events already counted per
+// time unit, e.g. the samples of another counter's AppendSnapshot. Each
+// sample adds its Count to the time unit that contains its Start.
+//
+// It returns the number of events added.
+func (c *Counter) ObserveBuckets(samples []BucketSample) int {
+	return c.observeBatch(len(samples), func(i int) (time.Time, uint32) {
+		return samples[i].Start, samples[i].Count
+	})
+}
+
+// observeBatch adds the n events returned by at, and returns the number of
+// events added
+func (c *Counter) observeBatch(n int, at func(i int) (time.Time, uint32)) int {
+	var added uint32
+	var last time.Time
+
+	if c.isPacked {
+		now := c.now()
+		crt := now.Truncate(c.Unit)
+		for i := 0; i < n; i++ {
+			t, count := at(i)
+			if t.Before(crt) || t.After(now) || count == 0 {
+				continue
+			}
+			added += count
+			last = maxTime(last, t)
+		}
+		if added > 0 {
+			c.addPacked(now, added)
+		}
+	} else {
+		now := c.refreshWindow()
+
+		c.mu.Lock()
+		for i := 0; i < n; i++ {
+			t, count := at(i)
+			if t.Before(c.windowStart) || t.After(now) || count == 0 {
+				continue
+			}
+			if j := int(t.Sub(c.windowStart) / c.Unit); j < len(c.prevCounts) {
+				c.prevCounts[j] += count
+			} else {
+				atomic.AddUint32(&c.crtCount, count)
+			}
+			added += count
+			last = maxTime(last, t)
+		}
+		c.mu.Unlock()
+	}
+
+	if added > 0 {
+		// Only move the most recent event forward
+		for {
+			old := c.lastObserved.Load()
+			if old >= last.UnixNano() || c.lastObserved.CompareAndSwap(old, last.UnixNano()) {
+				break
+			}
+		}
+	}
+	return int(added)
+}
@@ -0,0 +1,87 @@
+package hops_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestObserveBatch(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	at := func(seconds float64) time.Time { return start.Add(time.Duration(seconds * float64(time.Second))) }
+
+	tests := map[string]struct {
+		windowSize int
+		ts         []time.Time
+		wantAdded  int
+		wantCounts []uint32
+		wantLast   time.Time
+	}{
+		"empty": {
+			3, nil, 0, []uint32{0, 0, 0}, time.Time{},
+		},
+		"unordered": {
+			3, []time.Time{at(9.5), at(8), at(9), at(8.2), at(7)},
+			5, []uint32{1, 2, 2}, at(9.5),
+		},
+		"drops_old_and_future": {
+			3, []time.Time{at(6.9), at(8), at(10), at(9.9)},
+			2, []uint32{0, 1, 1}, at(9.9),
+		},
+		"single_unit": {
+			1, []time.Time{at(9.1), at(8.9), at(9.9), at(10)},
+			2, []uint32{2}, at(9.9),
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := hops.NewManualCounter(tt.windowSize, time.Second, start)
+			c.Tick(at(9.9))
+
+			if got := c.ObserveBatch(tt.ts); got != tt.wantAdded {
+				t.Errorf("expected added: %d, got: %d", tt.wantAdded, got)
+			}
+			if got := c.Snapshot().Counts; !reflect.DeepEqual(got, tt.wantCounts) {
+				t.Errorf("expected: %v, got: %v", tt.wantCounts, got)
+			}
+			if got := c.LastObserved(); !got.Equal(tt.wantLast) {
+				t.Errorf("expected: %v, got: %v", tt.wantLast, got)
+			}
+		})
+	}
+}
+
+func TestObserveBatchPacked(t *testing.T) {
+	c := hops.NewCounter(1, time.Hour)
+	now := time.Now()
+
+	added := c.ObserveBatch([]time.Time{now.Add(-time.Millisecond), now.Add(-2 * time.Hour), now.Add(time.Hour)})
+	if added > 1 || c.Value() != added {
+		t.Errorf("expected at most the recent event to be added, got: %d added, value %d", added, c.Value())
+	}
+}
+
+func TestObserveBuckets(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	src := hops.NewManualCounter(3, time.Second, start)
+	dst := hops.NewManualCounter(3, time.Second, start)
+
+	for i := 0; i < 4; i++ {
+		now := start.Add(time.Duration(i) * time.Second)
+		src.Tick(now)
+		dst.Tick(now)
+		for j := 0; j <= i; j++ {
+			src.Observe()
+		}
+	}
+
+	if got := dst.ObserveBuckets(src.AppendSnapshot(nil)); got != src.Value() {
+		t.Errorf("expected added: %d, got: %d", src.Value(), got)
+	}
+	if want, got := src.Snapshot().Counts, dst.Snapshot().Counts; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected: %v, got: %v", want, got)
+	}
+}
@@ -0,0 +1,23 @@
+package hops
+
+// WrapChan returns a channel that receives every value sent on in, and
+// observes an event on c for each of them. The returned channel has the
+// same capacity as in and is closed after in is closed.
+//
+// It turns a queue into a throughput metric without touching the code that
+// sends or receives on it:
+//
+//	jobs = hops.WrapChan(jobs, counter)
+//
+// Values are forwarded by a goroutine that runs until in is closed.
+func WrapChan[T any](in <-chan T, c *Counter) <-chan T {
+	out := make(chan T, cap(in))
+	go func() {
+		defer close(out)
+		for v := range in {
+			c.Observe()
+			out <- v
+		}
+	}()
+	return out
+}
@@ -0,0 +1,35 @@
+package hops_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestWrapChan(t *testing.T) {
+	c := hops.NewCounter(5, time.Minute)
+	in := make(chan int, 3)
+	out := hops.WrapChan(in, c)
+
+	if cap(out) != cap(in) {
+		t.Errorf("expected capacity: %d, got: %d", cap(in), cap(out))
+	}
+
+	in <- 1
+	in <- 2
+	in <- 3
+	close(in)
+
+	var got []int
+	for v := range out {
+		got = append(got, v)
+	}
+
+	if len(got) != 3 || got[0] != 1 || got[1] != 2 || got[2] != 3 {
+		t.Errorf("expected values in order: [1 2 3], got: %v", got)
+	}
+	if c.Value() != 3 {
+		t.Errorf("expected count: 3, got: %d", c.Value())
+	}
+}
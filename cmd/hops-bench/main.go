@@ -0,0 +1,269 @@
+// Command hops-bench drives synthetic load against hops counters and
+// limiters, and reports how accurate and how fast they are, so window
+// configurations can be validated before they reach production.
+//
+// The load follows a pattern: steady, bursty (all events in the first
+// fifth of every 5 seconds) or ramp (from nothing up to twice the rate).
+// It's simulated on a manual clock, so accuracy reports don't depend on
+// the speed of the machine:
+//
+//	hops-bench -pattern bursty -rate 200 -window 1m -unit 1s -limit 6000
+//
+// Throughput is measured separately, by observing events on a regular
+// counter from several goroutines for a second.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+// config describes a benchmark run
+type config struct {
+	pattern  string
+	rate     float64
+	duration time.Duration
+	window   time.Duration
+	unit     time.Duration
+
+	// Limiter to check, as accepted by hops.LimiterConfig, or none if the
+	// limit is 0
+	algorithm hops.Algorithm
+	limit     int
+}
+
+// counterReport measures how far a counter strays from an exact sliding
+// window
+type counterReport struct {
+	events int
+
+	// Mean and largest absolute difference between the value of the counter
+	// and the exact number of events within the last window
+	meanError float64
+	maxError  int
+
+	// Mean exact number of events within the last window
+	meanExact float64
+}
+
+// limiterReport measures how closely a limiter enforces its limit
+type limiterReport struct {
+	events  int
+	allowed int
+
+	// Largest number of allowed events within any window
+	maxInWindow int
+}
+
+func main() {
+	var cfg config
+	var algorithm string
+	flag.StringVar(&cfg.pattern, "pattern", "steady", "load pattern: steady, bursty or ramp")
+	flag.Float64Var(&cfg.rate, "rate", 100, "average number of events per second")
+	flag.DurationVar(&cfg.duration, "duration", 5*time.Minute, "simulated duration of the load")
+	flag.DurationVar(&cfg.window, "window", time.Minute, "window of the counter and the limiter")
+	flag.DurationVar(&cfg.unit, "unit", time.Second, "time unit of the counter and the sliding window limiter")
+	flag.StringVar(&algorithm, "algorithm", string(hops.SlidingWindow), "limiter algorithm: sliding_window, token_bucket, gcra or sliding_log")
+	flag.IntVar(&cfg.limit, "limit", 0, "events allowed by the limiter within the window, or 0 to skip the limiter")
+	goroutines := flag.Int("goroutines", runtime.GOMAXPROCS(0), "goroutines observing events in the throughput test")
+	flag.Parse()
+	cfg.algorithm = hops.Algorithm(algorithm)
+
+	if err := run(os.Stdout, cfg, *goroutines); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run simulates the load, measures throughput and writes the reports
+func run(w io.Writer, cfg config, goroutines int) error {
+	rate, err := pattern(cfg.pattern)
+	if err != nil {
+		return err
+	}
+	if cfg.unit <= 0 || cfg.window%cfg.unit != 0 {
+		return fmt.Errorf("unit %v doesn't divide window %v", cfg.unit, cfg.window)
+	}
+
+	cr := simulateCounter(cfg, rate)
+	fmt.Fprintf(w, "counter  %d events, %s pattern at %.0f/s, window %v, unit %v\n",
+		cr.events, cfg.pattern, cfg.rate, cfg.window, cfg.unit)
+	fmt.Fprintf(w, "  mean error %.2f events (%.2f%% of the mean window total), max error %d events\n",
+		cr.meanError, 100*cr.meanError/math.Max(cr.meanExact, 1), cr.maxError)
+
+	if cfg.limit > 0 {
+		lr, err := simulateLimiter(cfg, rate)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "limiter  %s, limit %d per %v\n", cfg.algorithm, cfg.limit, cfg.window)
+		fmt.Fprintf(w, "  allowed %d of %d events, at most %d within a window (%+d over the limit)\n",
+			lr.allowed, lr.events, lr.maxInWindow, lr.maxInWindow-cfg.limit)
+	}
+
+	n := throughput(goroutines, time.Second)
+	fmt.Fprintf(w, "throughput  %.1fM observations/s with %d goroutines\n", float64(n)/1e6, goroutines)
+	return nil
+}
+
+// pattern returns the rate of events, relative to the average rate, at the
+// given time of the simulation
+func pattern(name string) (func(elapsed, total time.Duration) float64, error) {
+	switch name {
+	case "steady":
+		return func(time.Duration, time.Duration) float64 { return 1 }, nil
+	case "bursty":
+		return func(elapsed, _ time.Duration) float64 {
+			if elapsed%(5*time.Second) < time.Second {
+				return 5
+			}
+			return 0
+		}, nil
+	case "ramp":
+		return func(elapsed, total time.Duration) float64 {
+			return 2 * float64(elapsed) / float64(total)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown pattern %q", name)
+	}
+}
+
+// simulate calls fn with the time instant of every event of the load
+func simulate(cfg config, rate func(elapsed, total time.Duration) float64, start time.Time, fn func(t time.Time)) {
+	step := min(cfg.unit/10, 10*time.Millisecond)
+	pending := 0.0
+	for elapsed := time.Duration(0); elapsed < cfg.duration; elapsed += step {
+		pending += cfg.rate * rate(elapsed, cfg.duration) * step.Seconds()
+		for ; pending >= 1; pending-- {
+			fn(start.Add(elapsed))
+		}
+	}
+}
+
+// simulateCounter compares a counter against an exact sliding window,
+// after every event
+func simulateCounter(cfg config, rate func(elapsed, total time.Duration) float64) counterReport {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	c := hops.NewManualCounter(int(cfg.window/cfg.unit), cfg.unit, start)
+
+	var r counterReport
+	var times []time.Time
+	var sumError, sumExact float64
+	simulate(cfg, rate, start, func(t time.Time) {
+		c.Tick(t)
+		c.Observe()
+		r.events++
+
+		// Drop the events that left the exact window
+		times = append(times, t)
+		for len(times) > 0 && !times[0].After(t.Add(-cfg.window)) {
+			times = times[1:]
+		}
+
+		diff := c.Value() - len(times)
+		r.maxError = max(r.maxError, abs(diff))
+		sumError += float64(abs(diff))
+		sumExact += float64(len(times))
+	})
+
+	if r.events > 0 {
+		r.meanError = sumError / float64(r.events)
+		r.meanExact = sumExact / float64(r.events)
+	}
+	return r
+}
+
+// simulateLimiter offers every event to a limiter, and checks how many
+// of them it allowed within any window
+func simulateLimiter(cfg config, rate func(elapsed, total time.Duration) float64) (limiterReport, error) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+
+	type manualLimiter interface {
+		hops.Limiter
+		Tick(now time.Time)
+	}
+	var l manualLimiter
+	switch cfg.algorithm {
+	case hops.SlidingWindow, "":
+		c := hops.NewManualCounter(int(cfg.window/cfg.unit), cfg.unit, start)
+		l = manualWindowLimiter{hops.NewWindowLimiter(c, cfg.limit), c}
+	case hops.TokenBucketAlgorithm:
+		l = hops.NewManualTokenBucket(float64(cfg.limit)/cfg.window.Seconds(), cfg.limit, start)
+	case hops.GCRAAlgorithm:
+		l = hops.NewManualGCRA(cfg.limit, cfg.window, cfg.limit, start)
+	case hops.SlidingLogAlgorithm:
+		l = hops.NewManualSlidingLog(cfg.limit, cfg.window, start)
+	default:
+		return limiterReport{}, fmt.Errorf("unknown algorithm %q", cfg.algorithm)
+	}
+
+	var r limiterReport
+	var allowed []time.Time
+	simulate(cfg, rate, start, func(t time.Time) {
+		l.Tick(t)
+		r.events++
+		if !l.Allow() {
+			return
+		}
+		r.allowed++
+
+		allowed = append(allowed, t)
+		for len(allowed) > 0 && !allowed[0].After(t.Add(-cfg.window)) {
+			allowed = allowed[1:]
+		}
+		r.maxInWindow = max(r.maxInWindow, len(allowed))
+	})
+	return r, nil
+}
+
+// manualWindowLimiter ticks the manual counter of a window limiter
+type manualWindowLimiter struct {
+	*hops.WindowLimiter
+	c *hops.Counter
+}
+
+func (l manualWindowLimiter) Tick(now time.Time) {
+	l.c.Tick(now)
+}
+
+// throughput returns how many events the given number of goroutines
+// observe on a counter within d
+func throughput(goroutines int, d time.Duration) int64 {
+	c := hops.NewCounter(60, time.Second)
+
+	var total atomic.Int64
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n := int64(0)
+			for ; !stop.Load(); n++ {
+				c.Observe()
+			}
+			total.Add(n)
+		}()
+	}
+
+	time.Sleep(d)
+	stop.Store(true)
+	wg.Wait()
+	return total.Load()
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
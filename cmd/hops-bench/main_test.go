@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestSimulateCounter(t *testing.T) {
+	tests := map[string]struct {
+		pattern    string
+		wantEvents int
+	}{
+		"steady": {"steady", 6000},
+		"bursty": {"bursty", 6000},
+		"ramp":   {"ramp", 6000},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			rate, err := pattern(tt.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg := config{rate: 100, duration: time.Minute, window: 10 * time.Second, unit: time.Second}
+			r := simulateCounter(cfg, rate)
+
+			if diff := r.events - tt.wantEvents; abs(diff) > tt.wantEvents/100 {
+				t.Errorf("expected about %d events, got: %d", tt.wantEvents, r.events)
+			}
+			// A hopping window counts at most one extra time unit
+			if r.maxError > 5*100 {
+				t.Errorf("expected an error under one unit of events, got: %d", r.maxError)
+			}
+		})
+	}
+}
+
+func TestSimulateLimiter(t *testing.T) {
+	rate, _ := pattern("bursty")
+
+	for _, algorithm := range []hops.Algorithm{
+		hops.SlidingWindow, hops.GCRAAlgorithm, hops.SlidingLogAlgorithm,
+	} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			cfg := config{
+				rate: 100, duration: time.Minute, window: 10 * time.Second, unit: time.Second,
+				algorithm: algorithm, limit: 500,
+			}
+			r, err := simulateLimiter(cfg, rate)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.allowed == 0 || r.allowed >= r.events {
+				t.Errorf("expected some events to be denied, got: %d of %d allowed", r.allowed, r.events)
+			}
+			if algorithm == hops.SlidingLogAlgorithm && r.maxInWindow > cfg.limit {
+				t.Errorf("expected the sliding log to be exact, got: %d in a window", r.maxInWindow)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	var b strings.Builder
+	cfg := config{pattern: "steady", rate: 10, duration: time.Minute, window: time.Minute, unit: time.Second, limit: 100}
+	if err := run(&b, cfg, 1); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"counter", "limiter", "throughput"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("expected a %s report, got:\n%s", want, b.String())
+		}
+	}
+
+	if err := run(&b, config{pattern: "sawtooth", unit: time.Second, window: time.Minute}, 1); err == nil {
+		t.Errorf("expected an error for an unknown pattern")
+	}
+}
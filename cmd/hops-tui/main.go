@@ -0,0 +1,181 @@
+// Command hops-tui shows the counters and gauges of a service live in the
+// terminal, for environments where only SSH is available.
+//
+// It connects to the dashboard of the service, as served by
+// hops.NewDashboard, and renders a sparkline of the recent values of each
+// metric, along with a table of the metrics with the highest values:
+//
+//	hops-tui -url http://localhost:8080/debug/hops/
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// event is the payload of an event streamed by the dashboard
+type event struct {
+	Time   time.Time          `json:"time"`
+	Values map[string]int     `json:"values"`
+	Gauges map[string]float64 `json:"gauges"`
+}
+
+// state holds the recent values of every counter and gauge
+type state struct {
+	// Most recent values of each metric, oldest first
+	history map[string][]float64
+
+	// Time of the most recent event
+	time time.Time
+
+	// Maximum number of values kept for each metric
+	size int
+}
+
+func main() {
+	url := flag.String("url", "http://localhost:8080/debug/hops/", "URL of the hops dashboard of the service")
+	interval := flag.Duration("interval", time.Second, "how often values are refreshed")
+	size := flag.Int("history", 60, "number of values shown in each sparkline")
+	top := flag.Int("top", 10, "number of metrics shown in the table of highest values")
+	flag.Parse()
+
+	endpoint := strings.TrimSuffix(*url, "/") + "/events?interval=" + interval.String()
+	resp, err := http.Get(endpoint)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		log.Fatalf("unexpected response from %s: %s", endpoint, resp.Status)
+	}
+
+	s := &state{history: make(map[string][]float64), size: *size}
+	err = readEvents(resp.Body, func(e event) {
+		s.add(e)
+		// Clear the screen and move the cursor to the top left corner
+		fmt.Print("\x1b[H\x1b[2J")
+		s.render(os.Stdout, *top)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// readEvents calls fn with every event of a stream of Server-Sent Events,
+// until the stream ends
+func readEvents(r io.Reader, fn func(event)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+
+		var e event
+		if err := json.Unmarshal([]byte(data), &e); err != nil {
+			return fmt.Errorf("malformed event: %w", err)
+		}
+		fn(e)
+	}
+	return scanner.Err()
+}
+
+// add appends the values of an event to the history of each metric
+func (s *state) add(e event) {
+	s.time = e.Time
+	for name, v := range e.Values {
+		s.append(name, float64(v))
+	}
+	for name, v := range e.Gauges {
+		s.append(name, v)
+	}
+
+	// Forget the counters that were unregistered, and the gauges without
+	// recent samples
+	for name := range s.history {
+		_, isCounter := e.Values[name]
+		_, isGauge := e.Gauges[name]
+		if !isCounter && !isGauge {
+			delete(s.history, name)
+		}
+	}
+}
+
+// append adds v to the history of the given metric
+func (s *state) append(name string, v float64) {
+	h := append(s.history[name], v)
+	if len(h) > s.size {
+		h = h[len(h)-s.size:]
+	}
+	s.history[name] = h
+}
+
+// render writes a sparkline for every metric, in order of their names,
+// followed by the top metrics with the highest values
+func (s *state) render(w io.Writer, top int) {
+	names := make([]string, 0, len(s.history))
+	width := 0
+	for name := range s.history {
+		names = append(names, name)
+		width = max(width, len(name))
+	}
+	slices.Sort(names)
+
+	fmt.Fprintf(w, "hops  %s\n\n", s.time.Format(time.TimeOnly))
+	for _, name := range names {
+		h := s.history[name]
+		fmt.Fprintf(w, "%-*s  %s %g\n", width, name, sparkline(h), h[len(h)-1])
+	}
+
+	// Highest values first, ties in order of names
+	slices.SortStableFunc(names, func(a, b string) int {
+		return cmp.Compare(s.latest(b), s.latest(a))
+	})
+	if len(names) > top {
+		names = names[:top]
+	}
+
+	fmt.Fprintf(w, "\nTop %d\n", len(names))
+	for i, name := range names {
+		fmt.Fprintf(w, "%2d. %-*s  %g\n", i+1, width, name, s.latest(name))
+	}
+}
+
+// latest returns the most recent value of the given metric
+func (s *state) latest(name string) float64 {
+	h := s.history[name]
+	return h[len(h)-1]
+}
+
+// sparkline draws the values as a line of blocks whose heights are
+// proportional to the values
+func sparkline(values []float64) string {
+	const blocks = "▁▂▃▄▅▆▇█"
+	levels := []rune(blocks)
+
+	largest := 0.0
+	for _, v := range values {
+		largest = max(largest, v)
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		level := 0
+		if largest > 0 {
+			level = int(v * float64(len(levels)-1) / largest)
+		}
+		b.WriteRune(levels[level])
+	}
+	return b.String()
+}
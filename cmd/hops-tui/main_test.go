@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSparkline(t *testing.T) {
+	tests := map[string]struct {
+		values []float64
+		want   string
+	}{
+		"empty":  {nil, ""},
+		"zeroes": {[]float64{0, 0, 0}, "▁▁▁"},
+		"ramp":   {[]float64{0, 1, 2, 3, 4, 5, 6, 7}, "▁▂▃▄▅▆▇█"},
+		"scaled": {[]float64{10, 70, 35}, "▂█▄"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := sparkline(tt.values); got != tt.want {
+				t.Errorf("expected: %q, got: %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	stream := strings.Join([]string{
+		`data: {"time":"2021-03-14T15:09:26Z","values":{"jobs":1,"http.requests":5,"http.errors":2}}`,
+		``,
+		`data: {"time":"2021-03-14T15:09:27Z","values":{"jobs":3,"http.requests":7},"gauges":{"queue.size":2.5}}`,
+		``,
+	}, "\n")
+
+	s := &state{history: make(map[string][]float64), size: 60}
+	if err := readEvents(strings.NewReader(stream), s.add); err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	s.render(&b, 1)
+	want := strings.Join([]string{
+		"hops  15:09:27",
+		"",
+		"http.requests  ▆█ 7",
+		"jobs           ▃█ 3",
+		"queue.size     █ 2.5",
+		"",
+		"Top 1",
+		" 1. http.requests  7",
+		"",
+	}, "\n")
+	if got := b.String(); got != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+	}
+	if !s.time.Equal(time.Date(2021, 3, 14, 15, 9, 27, 0, time.UTC)) {
+		t.Errorf("unexpected time: %v", s.time)
+	}
+}
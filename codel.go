@@ -0,0 +1,155 @@
+package hops
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CoDel is an admission controller inspired by the CoDel queue management
+// algorithm. It tracks the queueing delay of requests, i.e. how long they
+// waited before being served, and sheds load once even the shortest delay
+// within the window is above a target.
+//
+// A short burst makes some requests wait, but lets others through quickly,
+// so the minimum delay stays low. A standing queue, where every request
+// waits, is the sign of overload.
+//
+// It's safe to use the controller concurrently.
+type CoDel struct {
+	// Guards slots and tickTime
+	mu sync.Mutex
+
+	// Minimum delays of each time unit of the window
+	slots *Ring[codelSlot]
+
+	// Set for controllers that are advanced explicitly through Tick
+	manual bool
+
+	// Latest time instant passed to Tick
+	tickTime time.Time
+
+	target time.Duration
+}
+
+// codelSlot holds the minimum queueing delay of a time unit
+type codelSlot struct {
+	// Set once a delay is recorded in the time unit
+	valid bool
+
+	minDelay time.Duration
+}
+
+// NewCoDel creates an admission controller that sheds load once the
+// queueing delay stays above target for a whole window.
+//
+// For example, NewCoDel(10, 10*time.Millisecond, 5*time.Millisecond) sheds
+// load once requests waited at least 5ms for 100ms.
+func NewCoDel(windowSize int, timeUnit, target time.Duration) *CoDel {
+	return &CoDel{slots: NewRing[codelSlot](windowSize, timeUnit), target: target}
+}
+
+// NewManualCoDel creates an admission controller that doesn't follow the
+// wall clock. It only moves forward when the application calls Tick.
+func NewManualCoDel(windowSize int, timeUnit, target time.Duration, now time.Time) *CoDel {
+	c := NewCoDel(windowSize, timeUnit, target)
+	c.manual = true
+	c.tickTime = now
+	return c
+}
+
+// Admit records the queueing delay of a request and reports whether the
+// request should be served. While overloaded, requests that waited longer
+// than the target are rejected, since their clients have likely given up
+// on them already.
+func (c *CoDel) Admit(delay time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	overloaded := c.overloaded()
+
+	if slot := c.slots.Bucket(c.now()); slot != nil {
+		if !slot.valid || delay < slot.minDelay {
+			slot.minDelay = delay
+		}
+		slot.valid = true
+	}
+
+	return !overloaded || delay <= c.target
+}
+
+// Overloaded reports whether the minimum queueing delay within the window
+// is above the target
+func (c *CoDel) Overloaded() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.overloaded()
+}
+
+// Tick advances a manual controller to the given time instant. Time
+// instants older than the latest one passed to Tick are ignored.
+//
+// Tick has no effect on controllers that aren't created by NewManualCoDel.
+func (c *CoDel) Tick(now time.Time) {
+	if !c.manual {
+		return
+	}
+
+	c.mu.Lock()
+	if now.After(c.tickTime) {
+		c.tickTime = now
+	}
+	c.mu.Unlock()
+}
+
+// overloaded is like Overloaded, but must be called with mu held.
+// Every time unit of the window must have a minimum delay above the target,
+// so the controller doesn't trip on a single slow request. The current time
+// unit is skipped until a delay is recorded in it.
+func (c *CoDel) overloaded() bool {
+	now := c.now()
+	units, current := 0, false
+	for start, slot := range c.slots.All(now) {
+		if !slot.valid || slot.minDelay <= c.target {
+			return false
+		}
+		units++
+		current = now.Sub(start) < c.slots.Unit()
+	}
+
+	// The current time unit may have just started
+	return units == c.slots.Size() || (units == c.slots.Size()-1 && !current)
+}
+
+// now returns the current time instant as seen by the controller.
+// It must be called with mu held.
+func (c *CoDel) now() time.Time {
+	if !c.manual {
+		return time.Now()
+	}
+	return c.tickTime
+}
+
+// AdmissionControl returns an HTTP handler that serves at most maxInFlight
+// requests at a time with next, and queues the others. The time requests
+// spend in the queue is tracked by c, and requests it doesn't admit are
+// rejected with 503 Service Unavailable.
+func AdmissionControl(c *CoDel, maxInFlight int, next http.Handler) http.Handler {
+	slots := make(chan struct{}, maxInFlight)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived := time.Now()
+		select {
+		case slots <- struct{}{}:
+		case <-r.Context().Done():
+			return
+		}
+		defer func() { <-slots }()
+
+		if !c.Admit(time.Since(arrived)) {
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,76 @@
+package hops_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestCoDel(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	target := 5 * time.Millisecond
+
+	tests := map[string]struct {
+		// Queueing delays of each time unit
+		delays [][]time.Duration
+		want   bool
+	}{
+		"idle":      {nil, false},
+		"fast":      {[][]time.Duration{{time.Millisecond}, {2 * time.Millisecond}, {0}}, false},
+		"burst":     {[][]time.Duration{{time.Millisecond}, {20 * time.Millisecond, time.Millisecond}, {0}}, false},
+		"one_slow":  {[][]time.Duration{{time.Millisecond}, {time.Millisecond}, {50 * time.Millisecond}}, false},
+		"partial":   {[][]time.Duration{{10 * time.Millisecond}, {10 * time.Millisecond}}, false},
+		"standing":  {[][]time.Duration{{10 * time.Millisecond}, {8 * time.Millisecond, 6 * time.Millisecond}, {9 * time.Millisecond}}, true},
+		"recovered": {[][]time.Duration{{10 * time.Millisecond}, {10 * time.Millisecond}, {10 * time.Millisecond}, {time.Millisecond}}, false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := hops.NewManualCoDel(3, time.Second, target, start)
+			for i, delays := range tt.delays {
+				c.Tick(start.Add(time.Duration(i) * time.Second))
+				for _, d := range delays {
+					c.Admit(d)
+				}
+			}
+
+			if got := c.Overloaded(); got != tt.want {
+				t.Errorf("expected: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCoDelAdmit(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	c := hops.NewManualCoDel(2, time.Second, 5*time.Millisecond, start)
+	if !c.Admit(10 * time.Millisecond) {
+		t.Fatal("expected requests to be admitted until overloaded")
+	}
+
+	// Every completed time unit of the window was slow
+	c.Tick(start.Add(time.Second))
+	if c.Admit(10 * time.Millisecond) {
+		t.Errorf("expected a slow request to be rejected while overloaded")
+	}
+	if !c.Admit(time.Millisecond) {
+		t.Errorf("expected a fast request to be admitted while overloaded")
+	}
+	if c.Overloaded() {
+		t.Errorf("expected the fast request to end the overload")
+	}
+}
+
+func TestAdmissionControl(t *testing.T) {
+	c := hops.NewCoDel(10, time.Second, time.Hour)
+	h := hops.AdmissionControl(c, 1, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected status: %d, got: %d", http.StatusOK, rec.Code)
+	}
+}
@@ -0,0 +1,61 @@
+package hops
+
+import "math"
+
+// Comparison holds the result of comparing the windowed rates of two
+// counters, a baseline and a canary
+type Comparison struct {
+	// Events per second within the window of each counter
+	BaselineRate float64
+	CanaryRate   float64
+
+	// Difference between the canary rate and the baseline rate, relative
+	// to the baseline rate. For example, 0.25 means the canary rate is 25%
+	// higher. It's +Inf if only the canary has events.
+	RelativeDiff float64
+
+	// Two-sided p-value of the hypothesis that both counters see events at
+	// the same rate. Small values (e.g. under 0.05) mean the difference is
+	// unlikely to be due to chance alone.
+	PValue float64
+}
+
+// Significant reports whether the difference between the rates is
+// statistically significant at the given level (e.g. 0.05)
+func (c Comparison) Significant(alpha float64) bool {
+	return c.PValue < alpha
+}
+
+// Compare compares the windowed rates of a baseline and a canary counter,
+// e.g. the errors of the stable and the newly deployed version of a service.
+//
+// Rates are normalized by window size, so the counters don't need to share
+// the same window. Significance is computed with a normal approximation of
+// the difference between two Poisson rates, which is accurate once each
+// window holds a few dozen events.
+func Compare(baseline, canary *Counter) Comparison {
+	b := float64(baseline.Value())
+	c := float64(canary.Value())
+	tb := baseline.WindowSize.Seconds()
+	tc := canary.WindowSize.Seconds()
+
+	cmp := Comparison{
+		BaselineRate: b / tb,
+		CanaryRate:   c / tc,
+		PValue:       1,
+	}
+
+	switch {
+	case cmp.BaselineRate > 0:
+		cmp.RelativeDiff = (cmp.CanaryRate - cmp.BaselineRate) / cmp.BaselineRate
+	case cmp.CanaryRate > 0:
+		cmp.RelativeDiff = math.Inf(1)
+	}
+
+	if variance := b/(tb*tb) + c/(tc*tc); variance > 0 {
+		z := (cmp.CanaryRate - cmp.BaselineRate) / math.Sqrt(variance)
+		cmp.PValue = math.Erfc(math.Abs(z) / math.Sqrt2)
+	}
+
+	return cmp
+}
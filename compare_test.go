@@ -0,0 +1,74 @@
+package hops_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestCompare(t *testing.T) {
+	observe := func(c *hops.Counter, n int) *hops.Counter {
+		for i := 0; i < n; i++ {
+			c.Observe()
+		}
+		return c
+	}
+
+	tests := map[string]struct {
+		baseline, canary *hops.Counter
+		wantDiff         float64
+		wantSignificant  bool
+	}{
+		"same_rate": {
+			observe(hops.NewCounter(5, time.Minute), 100),
+			observe(hops.NewCounter(5, time.Minute), 100),
+			0,
+			false,
+		},
+		"small_sample": {
+			observe(hops.NewCounter(5, time.Minute), 4),
+			observe(hops.NewCounter(5, time.Minute), 6),
+			0.5,
+			false,
+		},
+		"canary_doubles_the_rate": {
+			observe(hops.NewCounter(5, time.Minute), 200),
+			observe(hops.NewCounter(5, time.Minute), 400),
+			1,
+			true,
+		},
+		"different_window_sizes": {
+			observe(hops.NewCounter(10, time.Minute), 200),
+			observe(hops.NewCounter(5, time.Minute), 100),
+			0,
+			false,
+		},
+		"no_events": {
+			hops.NewCounter(5, time.Minute),
+			hops.NewCounter(5, time.Minute),
+			0,
+			false,
+		},
+		"only_canary_events": {
+			hops.NewCounter(5, time.Minute),
+			observe(hops.NewCounter(5, time.Minute), 50),
+			math.Inf(1),
+			true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cmp := hops.Compare(tt.baseline, tt.canary)
+			if math.Abs(cmp.RelativeDiff-tt.wantDiff) > 1e-9 && cmp.RelativeDiff != tt.wantDiff {
+				t.Errorf("expected relative diff: %v, got: %v", tt.wantDiff, cmp.RelativeDiff)
+			}
+			if cmp.Significant(0.05) != tt.wantSignificant {
+				t.Errorf("expected significant: %v, got: %v (p=%v)",
+					tt.wantSignificant, cmp.Significant(0.05), cmp.PValue)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package hops
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ConcurrencyLimiter caps the number of requests in flight, and adapts the
+// cap to the health of the backend with AIMD (additive increase,
+// multiplicative decrease), in the style of Netflix's concurrency-limits.
+//
+// It tracks the latencies and failures of requests within a hopping window.
+// Once a request finishes in a new time unit, the cap is adjusted:
+//
+//   - decreased by a tenth if more than a tenth of the requests within the
+//     window were slower than the target latency or failed, at most once per
+//     window, so the effect of a decrease is seen before the next one
+//   - increased by one if the cap was reached in the previous time unit
+//     and the window is healthy
+//
+// ConcurrencyLimit and ConcurrencyLimitUnary plug it into HTTP and gRPC
+// servers.
+//
+// It's safe to use the limiter concurrently.
+type ConcurrencyLimiter struct {
+	// Guards all the fields below
+	mu sync.Mutex
+
+	// Requests of each time unit of the window
+	slots *Ring[concurrencySlot]
+
+	// Set for limiters that are advanced explicitly through Tick
+	manual bool
+
+	// Latest time instant passed to Tick
+	tickTime time.Time
+
+	target   time.Duration
+	maxLimit int
+
+	limit    int
+	inFlight int
+
+	// Time unit, counted from the Unix epoch, of the latest adjustment of
+	// the limit and of the latest decrease
+	adjusted  int64
+	decreased int64
+}
+
+// concurrencySlot holds the requests that finished in a time unit
+type concurrencySlot struct {
+	// Number of requests, and how many of them were slow or failed
+	requests int
+	bad      int
+
+	// Set when a request was rejected because the limit was reached
+	saturated bool
+}
+
+// NewConcurrencyLimiter creates a limiter that tracks requests within the
+// given window, and keeps their latency under target by allowing between 1
+// and maxLimit requests in flight. It starts at half of maxLimit.
+//
+// For example, NewConcurrencyLimiter(10, time.Second, 100*time.Millisecond,
+// 200) adapts to keep requests under 100ms, judging by the last 10 seconds.
+func NewConcurrencyLimiter(windowSize int, timeUnit, target time.Duration, maxLimit int) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{
+		slots:     NewRing[concurrencySlot](windowSize, timeUnit),
+		target:    target,
+		maxLimit:  maxLimit,
+		limit:     max(1, maxLimit/2),
+		adjusted:  -1,
+		decreased: -1,
+	}
+}
+
+// NewManualConcurrencyLimiter creates a limiter that doesn't follow the wall
+// clock. It only moves forward when the application calls Tick, which also
+// applies to the measured latencies.
+func NewManualConcurrencyLimiter(windowSize int, timeUnit, target time.Duration, maxLimit int, now time.Time) *ConcurrencyLimiter {
+	l := NewConcurrencyLimiter(windowSize, timeUnit, target, maxLimit)
+	l.manual = true
+	l.tickTime = now
+	return l
+}
+
+// Acquire reports whether a request may start now. If so, release must be
+// called once the request finishes, telling whether it failed, e.g. with an
+// overload or timeout error.
+func (l *ConcurrencyLimiter) Acquire() (release func(failed bool), ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if l.inFlight >= l.limit {
+		if slot := l.slots.Bucket(now); slot != nil {
+			slot.saturated = true
+		}
+		return nil, false
+	}
+	l.inFlight++
+
+	var once sync.Once
+	return func(failed bool) {
+		once.Do(func() { l.release(now, failed) })
+	}, true
+}
+
+// Limit returns the current number of requests allowed in flight
+func (l *ConcurrencyLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+// InFlight returns the number of requests in flight
+func (l *ConcurrencyLimiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inFlight
+}
+
+// Tick advances a manual limiter to the given time instant. Time instants
+// older than the latest one passed to Tick are ignored.
+//
+// Tick has no effect on limiters that aren't created by
+// NewManualConcurrencyLimiter.
+func (l *ConcurrencyLimiter) Tick(now time.Time) {
+	if !l.manual {
+		return
+	}
+
+	l.mu.Lock()
+	if now.After(l.tickTime) {
+		l.tickTime = now
+	}
+	l.mu.Unlock()
+}
+
+// release records a request that started at the given time instant, and
+// adjusts the limit if a new time unit started
+func (l *ConcurrencyLimiter) release(started time.Time, failed bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+
+	now := l.now()
+	if slot := l.slots.Bucket(now); slot != nil {
+		slot.requests++
+		if failed || now.Sub(started) > l.target {
+			slot.bad++
+		}
+	}
+
+	crt := now.UnixNano() / int64(l.slots.Unit())
+	if crt > l.adjusted {
+		l.adjust(now, crt)
+		l.adjusted = crt
+	}
+}
+
+// adjust updates the limit from the requests within the window.
+// It must be called with mu held.
+func (l *ConcurrencyLimiter) adjust(now time.Time, crt int64) {
+	var requests, bad int
+	saturated := false
+	for start, slot := range l.slots.All(now) {
+		requests += slot.requests
+		bad += slot.bad
+		if start.UnixNano()/int64(l.slots.Unit()) == crt-1 {
+			saturated = slot.saturated
+		}
+	}
+
+	switch {
+	case bad*10 > requests:
+		if l.decreased < 0 || crt-l.decreased >= int64(l.slots.Size()) {
+			l.limit = max(1, l.limit*9/10)
+			l.decreased = crt
+		}
+	case saturated:
+		l.limit = min(l.maxLimit, l.limit+1)
+	}
+}
+
+// now returns the current time instant as seen by the limiter.
+// It must be called with mu held.
+func (l *ConcurrencyLimiter) now() time.Time {
+	if !l.manual {
+		return time.Now()
+	}
+	return l.tickTime
+}
+
+// ConcurrencyLimit returns an HTTP handler that serves requests with next
+// as long as l allows them, and rejects the others with 503 Service
+// Unavailable. Responses with a 5xx status count as failures.
+func ConcurrencyLimit(l *ConcurrencyLimiter, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		release, ok := l.Acquire()
+		if !ok {
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		defer func() { release(sw.status >= 500) }()
+		next.ServeHTTP(sw, r)
+	})
+}
+
+// ConcurrencyLimitUnary returns a function with the shape of a gRPC unary
+// server interceptor, which serves calls with handler as long as l allows
+// them, and returns rejected for the others. Calls whose error is a
+// failure according to failed count as failures; a nil failed counts every
+// error. It has no dependency on gRPC, so servers adapt it themselves:
+//
+//	limit := hops.ConcurrencyLimitUnary(l, status.Error(codes.ResourceExhausted, "overloaded"), nil)
+//	grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+//		err = limit(ctx, func(ctx context.Context) error {
+//			resp, err = handler(ctx, req)
+//			return err
+//		})
+//		return resp, err
+//	})
+func ConcurrencyLimitUnary(l *ConcurrencyLimiter, rejected error, failed func(error) bool) func(ctx context.Context, handler func(context.Context) error) error {
+	if failed == nil {
+		failed = func(err error) bool { return err != nil }
+	}
+
+	return func(ctx context.Context, handler func(context.Context) error) (err error) {
+		release, ok := l.Acquire()
+		if !ok {
+			return rejected
+		}
+
+		defer func() { release(err != nil && failed(err)) }()
+		return handler(ctx)
+	}
+}
+
+// statusWriter records the status of a response
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap returns the original writer, for http.ResponseController
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,181 @@
+package hops_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestConcurrencyLimiterIncrease(t *testing.T) {
+	now := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	l := hops.NewManualConcurrencyLimiter(3, time.Second, 100*time.Millisecond, 6, now)
+
+	var releases []func(bool)
+	for i := 0; i < 3; i++ {
+		release, ok := l.Acquire()
+		if !ok {
+			t.Fatalf("expected request %d to be allowed", i+1)
+		}
+		releases = append(releases, release)
+	}
+	if _, ok := l.Acquire(); ok {
+		t.Errorf("expected the limit of 3 to be reached")
+	}
+	if got := l.InFlight(); got != 3 {
+		t.Errorf("expected: 3 in flight, got: %d", got)
+	}
+	for _, release := range releases {
+		release(false)
+	}
+
+	// Once the limit was reached without harm, it grows by one per time
+	// unit, up to the maximum
+	tests := []struct {
+		saturate  bool
+		wantLimit int
+	}{
+		{false, 4},
+		// Not reached in the previous time unit
+		{true, 4},
+		{false, 5},
+		{false, 5},
+		{true, 5},
+		{true, 6},
+		{true, 6},
+	}
+	for i, tt := range tests {
+		now = now.Add(time.Second)
+		l.Tick(now)
+
+		release, _ := l.Acquire()
+		release(false)
+		if got := l.Limit(); got != tt.wantLimit {
+			t.Errorf("step %d: expected limit: %d, got: %d", i, tt.wantLimit, got)
+		}
+
+		if tt.saturate {
+			var releases []func(bool)
+			for {
+				release, ok := l.Acquire()
+				if !ok {
+					break
+				}
+				releases = append(releases, release)
+			}
+			for _, release := range releases {
+				release(false)
+			}
+		}
+	}
+}
+
+func TestConcurrencyLimiterDecrease(t *testing.T) {
+	now := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	l := hops.NewManualConcurrencyLimiter(3, time.Second, 100*time.Millisecond, 40, now)
+
+	tests := []struct {
+		advance   time.Duration
+		failed    bool
+		wantLimit int
+	}{
+		// Slow requests
+		{200 * time.Millisecond, false, 18},
+		// At most one decrease per window
+		{time.Second, false, 18},
+		{time.Second, false, 18},
+		{time.Second, false, 16},
+		// Failed requests
+		{3 * time.Second, true, 14},
+	}
+
+	for i, tt := range tests {
+		release, ok := l.Acquire()
+		if !ok {
+			t.Fatalf("step %d: expected the request to be allowed", i)
+		}
+		now = now.Add(tt.advance)
+		l.Tick(now)
+		if tt.failed {
+			// Fast, but failed
+			release, _ = l.Acquire()
+		}
+		release(tt.failed)
+
+		if got := l.Limit(); got != tt.wantLimit {
+			t.Errorf("step %d: expected limit: %d, got: %d", i, tt.wantLimit, got)
+		}
+	}
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	l := hops.NewConcurrencyLimiter(10, time.Second, time.Second, 2)
+
+	block := make(chan struct{})
+	started := make(chan struct{})
+	h := hops.ConcurrencyLimit(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-block
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		close(done)
+	}()
+	<-started
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected: %d, got: %d", http.StatusServiceUnavailable, rec.Code)
+	}
+
+	close(block)
+	<-done
+	if got := l.InFlight(); got != 0 {
+		t.Errorf("expected: 0 in flight, got: %d", got)
+	}
+}
+
+func TestConcurrencyLimitUnary(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	l := hops.NewManualConcurrencyLimiter(10, time.Second, time.Second, 20, start)
+
+	errOverloaded := errors.New("overloaded")
+	errInvalid := errors.New("invalid argument")
+	errUnavailable := errors.New("unavailable")
+	limit := hops.ConcurrencyLimitUnary(l, errOverloaded, func(err error) bool {
+		return !errors.Is(err, errInvalid)
+	})
+	call := func(err error) error {
+		return limit(context.Background(), func(context.Context) error { return err })
+	}
+
+	// Client errors aren't failures
+	if err := call(errInvalid); err != errInvalid {
+		t.Errorf("expected: %v, got: %v", errInvalid, err)
+	}
+	if got := l.Limit(); got != 10 {
+		t.Errorf("expected: 10, got: %d", got)
+	}
+
+	l.Tick(start.Add(time.Second))
+	if err := call(errUnavailable); err != errUnavailable {
+		t.Errorf("expected: %v, got: %v", errUnavailable, err)
+	}
+	if got := l.Limit(); got != 9 {
+		t.Errorf("expected the limit to decrease to 9, got: %d", got)
+	}
+
+	for i := 0; i < 9; i++ {
+		l.Acquire()
+	}
+	if err := call(nil); err != errOverloaded {
+		t.Errorf("expected: %v, got: %v", errOverloaded, err)
+	}
+}
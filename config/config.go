@@ -0,0 +1,242 @@
+// Package config builds hops counters and limiters from a declarative JSON
+// document, so a service can define all of them in one reviewed file.
+//
+// A document looks like this:
+//
+//	{
+//		"counters": [
+//			{"name": "http.requests", "window": "5m", "unit": "1m"}
+//		],
+//		"limiters": [
+//			{"name": "api", "algorithm": "sliding_window", "limit": 100, "window": "1m", "unit": "1s", "per_key": true}
+//		],
+//		"alerts": [
+//			{"name": "traffic_drop", "metric": "http.requests", "comparator": "<", "threshold": 10, "for": "5m", "severity": "critical"}
+//		]
+//	}
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+// Document is the declarative definition of counters, limiters and alert
+// rules
+type Document struct {
+	Counters []CounterSpec `json:"counters"`
+	Limiters []LimiterSpec `json:"limiters"`
+	Alerts   []AlertSpec   `json:"alerts"`
+}
+
+// CounterSpec defines a counter
+type CounterSpec struct {
+	// Name under which the counter is registered
+	Name string `json:"name"`
+
+	// Period covered by the counter, e.g. "5m"
+	Window Duration `json:"window"`
+
+	// Hop size of the counter, e.g. "1m". It must divide Window.
+	Unit Duration `json:"unit"`
+}
+
+// LimiterSpec defines a limiter
+type LimiterSpec struct {
+	// Name of the limiter. Its allowed and denied counters are registered
+	// under this name followed by ".allowed" and ".denied".
+	Name string `json:"name"`
+
+	// Algorithm used to enforce the limit, e.g. "token_bucket". Defaults to
+	// a sliding window.
+	Algorithm hops.Algorithm `json:"algorithm"`
+
+	// Maximum number of events allowed within Window
+	Limit int `json:"limit"`
+
+	// Period over which Limit events are allowed, e.g. "1m"
+	Window Duration `json:"window"`
+
+	// Hop size of a sliding window, e.g. "1s"
+	Unit Duration `json:"unit"`
+
+	// Whether the limit applies to each key separately
+	PerKey bool `json:"per_key"`
+}
+
+// AlertSpec defines an alert rule, as described by hops.AlertRule
+type AlertSpec struct {
+	Name string `json:"name"`
+
+	// Query expression over the counters, e.g. "http.errors / http.requests"
+	Metric string `json:"metric"`
+
+	// One of ">", ">=", "<" and "<="
+	Comparator hops.Comparator `json:"comparator"`
+
+	Threshold float64 `json:"threshold"`
+
+	// How long the condition must hold before the alert fires, e.g. "5m"
+	For Duration `json:"for"`
+
+	Severity hops.Severity `json:"severity"`
+}
+
+// Duration is a time.Duration written as a string, such as "1m30s"
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("%w: durations must be strings such as \"5m\"", hops.ErrInvalidConfig)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("%w: %v", hops.ErrInvalidConfig, err)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalJSON formats the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Set holds the objects built from a document
+type Set struct {
+	// Registry with all counters, and the allowed and denied counters of
+	// all limiters
+	Registry *hops.Registry
+
+	// Limiters that apply to all events, by name. They're instrumented
+	// with hops.Instrument, and keep the optional methods of their
+	// algorithm, e.g. SetLimit, AllowPriority, or Reserve for a sliding
+	// window.
+	Limiters map[string]hops.Limiter
+
+	// Limiters that apply to each key separately, by name
+	KeyedLimiters map[string]*hops.KeyedLimiter
+
+	// Alerter with all alert rules, evaluated against Registry. It has no
+	// notifiers; add them with AddNotifier before running it.
+	Alerter *hops.Alerter
+}
+
+// Load reads a document from r and builds the objects it defines.
+// It fails with hops.ErrInvalidConfig if the document is malformed or
+// inconsistent, and with hops.ErrAlreadyRegistered if names are reused.
+func Load(r io.Reader) (*Set, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	var doc Document
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", hops.ErrInvalidConfig, err)
+	}
+	return Build(doc)
+}
+
+// LoadFile is like Load, but reads the document from a file
+func LoadFile(path string) (*Set, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Load(f)
+}
+
+// Build creates the objects defined by doc
+func Build(doc Document) (*Set, error) {
+	set := &Set{
+		Registry:      hops.NewRegistry(),
+		Limiters:      make(map[string]hops.Limiter),
+		KeyedLimiters: make(map[string]*hops.KeyedLimiter),
+	}
+	set.Alerter = hops.NewAlerter(set.Registry)
+
+	for _, spec := range doc.Counters {
+		window, unit := time.Duration(spec.Window), time.Duration(spec.Unit)
+		if spec.Name == "" || unit <= 0 || window < unit || window%unit != 0 {
+			return nil, fmt.Errorf("%w: counter %q needs a name, and a unit that divides its window",
+				hops.ErrInvalidConfig, spec.Name)
+		}
+		c := hops.NewCounter(int(window/unit), unit)
+		if err := set.Registry.Register(spec.Name, c); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, spec := range doc.Limiters {
+		if err := set.addLimiter(spec); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, spec := range doc.Alerts {
+		err := set.Alerter.AddRule(hops.AlertRule{
+			Name:       spec.Name,
+			Metric:     spec.Metric,
+			Comparator: spec.Comparator,
+			Threshold:  spec.Threshold,
+			For:        time.Duration(spec.For),
+			Severity:   spec.Severity,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return set, nil
+}
+
+// addLimiter builds the limiter defined by spec and registers its metrics
+func (s *Set) addLimiter(spec LimiterSpec) error {
+	if spec.Name == "" {
+		return fmt.Errorf("%w: limiters need a name", hops.ErrInvalidConfig)
+	}
+	if _, ok := s.Limiters[spec.Name]; ok {
+		return fmt.Errorf("%w: limiter %q", hops.ErrAlreadyRegistered, spec.Name)
+	}
+	if _, ok := s.KeyedLimiters[spec.Name]; ok {
+		return fmt.Errorf("%w: limiter %q", hops.ErrAlreadyRegistered, spec.Name)
+	}
+
+	cfg := hops.LimiterConfig{
+		Algorithm: spec.Algorithm,
+		Limit:     spec.Limit,
+		Window:    time.Duration(spec.Window),
+		Unit:      time.Duration(spec.Unit),
+	}
+
+	// Validate the configuration once, rather than for every new key
+	l, err := cfg.NewLimiter()
+	if err != nil {
+		return fmt.Errorf("limiter %q: %w", spec.Name, err)
+	}
+
+	metrics := hops.NewLimiterMetrics(60, time.Second, false)
+	if err := metrics.Register(s.Registry, spec.Name); err != nil {
+		return err
+	}
+
+	if !spec.PerKey {
+		s.Limiters[spec.Name] = hops.Instrument(l, metrics)
+		return nil
+	}
+
+	k := hops.NewKeyedLimiter(func() hops.Limiter {
+		l, _ := cfg.NewLimiter()
+		return l
+	})
+	k.SetMetrics(metrics)
+	s.KeyedLimiters[spec.Name] = k
+	return nil
+}
@@ -0,0 +1,156 @@
+package config_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+	"github.com/ocpodariu/hops/config"
+)
+
+func TestLoad(t *testing.T) {
+	doc := `{
+		"counters": [
+			{"name": "http.requests", "window": "5m", "unit": "1m"}
+		],
+		"limiters": [
+			{"name": "login", "algorithm": "sliding_log", "limit": 2, "window": "15m", "per_key": true},
+			{"name": "export", "algorithm": "token_bucket", "limit": 1, "window": "1h"}
+		],
+		"alerts": [
+			{"name": "traffic_drop", "metric": "http.requests", "comparator": "<", "threshold": 1, "severity": "critical"}
+		]
+	}`
+
+	set, err := config.Load(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := set.Registry.Get("http.requests")
+	if c == nil {
+		t.Fatal("expected the counter to be registered")
+	}
+	if c.WindowSize.Minutes() != 5 || c.Unit.Minutes() != 1 {
+		t.Errorf("expected a 5m window with 1m unit, got: %v/%v", c.WindowSize, c.Unit)
+	}
+
+	login := set.KeyedLimiters["login"]
+	if !login.Allow("alice") || !login.Allow("alice") || login.Allow("alice") {
+		t.Errorf("expected 2 logins per key")
+	}
+	if !login.Allow("bob") {
+		t.Errorf("expected keys to have separate limits")
+	}
+	if got := set.Registry.Get("login.denied").Value(); got != 1 {
+		t.Errorf("expected denied: 1, got: %d", got)
+	}
+
+	export := set.Limiters["export"]
+	if !export.Allow() || export.Allow() {
+		t.Errorf("expected 1 export")
+	}
+	if got := set.Registry.Get("export.allowed").Value(); got != 1 {
+		t.Errorf("expected allowed: 1, got: %d", got)
+	}
+
+	set.Alerter.Evaluate(time.Now())
+	if got := set.Alerter.State("traffic_drop"); got != hops.AlertFiring {
+		t.Errorf("expected: %v, got: %v", hops.AlertFiring, got)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := map[string]struct {
+		doc     string
+		wantErr error
+	}{
+		"malformed_json": {
+			`{"counters": [`,
+			hops.ErrInvalidConfig,
+		},
+		"unknown_field": {
+			`{"countres": []}`,
+			hops.ErrInvalidConfig,
+		},
+		"numeric_duration": {
+			`{"counters": [{"name": "a", "window": 300, "unit": "1m"}]}`,
+			hops.ErrInvalidConfig,
+		},
+		"unit_does_not_divide_window": {
+			`{"counters": [{"name": "a", "window": "5m", "unit": "2m"}]}`,
+			hops.ErrInvalidConfig,
+		},
+		"duplicate_counter": {
+			`{"counters": [{"name": "a", "window": "5m", "unit": "1m"}, {"name": "a", "window": "5m", "unit": "1m"}]}`,
+			hops.ErrAlreadyRegistered,
+		},
+		"invalid_limiter": {
+			`{"limiters": [{"name": "api", "algorithm": "leaky", "limit": 1, "window": "1m"}]}`,
+			hops.ErrInvalidConfig,
+		},
+		"unknown_comparator": {
+			`{"alerts": [{"name": "a", "metric": "b", "comparator": "=="}]}`,
+			hops.ErrInvalidConfig,
+		},
+		"duplicate_alert": {
+			`{"alerts": [{"name": "a", "metric": "b", "comparator": ">"}, {"name": "a", "metric": "c", "comparator": ">"}]}`,
+			hops.ErrAlreadyRegistered,
+		},
+		"limiter_metrics_clash_with_counter": {
+			`{"counters": [{"name": "api.denied", "window": "5m", "unit": "1m"}],
+			  "limiters": [{"name": "api", "limit": 1, "window": "1m"}]}`,
+			hops.ErrAlreadyRegistered,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := config.Load(strings.NewReader(tt.doc)); !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected: %v, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestBuildKeepsLimiterMethods(t *testing.T) {
+	set, err := config.Build(config.Document{
+		Limiters: []config.LimiterSpec{
+			{Name: "export", Algorithm: hops.SlidingWindow, Limit: 4, Window: config.Duration(time.Minute)},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := set.Limiters["export"]
+	s, ok := l.(interface{ SetLimit(int) })
+	if !ok {
+		t.Fatal("expected the limit to be adjustable")
+	}
+	s.SetLimit(2)
+
+	pl, ok := l.(hops.PriorityLimiter)
+	if !ok {
+		t.Fatal("expected priorities to be supported")
+	}
+	if !pl.AllowPriority(hops.PriorityCritical) {
+		t.Errorf("expected a critical event to be allowed")
+	}
+
+	r, ok := l.(interface{ Reserve() *hops.Reservation })
+	if !ok {
+		t.Fatal("expected reservations to be supported")
+	}
+	if res := r.Reserve(); !res.OK() || res.Delay() != 0 {
+		t.Errorf("expected the last event of the limit to be reserved right away")
+	}
+	if l.Allow() {
+		t.Errorf("expected the new limit to be reached")
+	}
+	if got := set.Registry.Get("export.allowed").Value(); got != 2 {
+		t.Errorf("expected allowed: 2, got: %d", got)
+	}
+}
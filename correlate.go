@@ -0,0 +1,101 @@
+package hops
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Correlation holds the result of correlating the per-unit counts of two
+// counters over their shared window
+type Correlation struct {
+	// Pearson correlation coefficient of the two series, between -1 and 1
+	Coefficient float64
+
+	// Lag, in time units, at which the two series are the most correlated.
+	// A positive lag means the events of the second counter follow the
+	// events of the first one.
+	Lag int
+
+	// Pearson correlation coefficient of the two series at Lag
+	LagCoefficient float64
+}
+
+// Correlate computes the correlation between the per-unit counts of a and b
+// over the time units covered by both windows, trying every lag between
+// -maxLag and maxLag time units.
+//
+// For example, with a counter of deploys and a counter of errors, a strong
+// coefficient at a positive lag answers "do error spikes follow deploys?".
+//
+// Both counters must have the same time unit, otherwise it fails with
+// ErrIncompatibleWindow. Series without any variation (e.g. no events at
+// all) have a coefficient of 0.
+func Correlate(a, b *Counter, maxLag int) (Correlation, error) {
+	if a.Unit != b.Unit {
+		return Correlation{}, fmt.Errorf("%w: time units %v and %v differ", ErrIncompatibleWindow, a.Unit, b.Unit)
+	}
+
+	x, y := alignSnapshots(a.Snapshot(), b.Snapshot())
+
+	corr := Correlation{Coefficient: pearson(x, y, 0)}
+	corr.LagCoefficient = corr.Coefficient
+	for lag := -maxLag; lag <= maxLag; lag++ {
+		if r := pearson(x, y, lag); math.Abs(r) > math.Abs(corr.LagCoefficient) {
+			corr.Lag = lag
+			corr.LagCoefficient = r
+		}
+	}
+	return corr, nil
+}
+
+// alignSnapshots returns the counts of a and b for the time units covered
+// by both snapshots, which must have the same time unit
+func alignSnapshots(a, b Snapshot) ([]uint32, []uint32) {
+	start := a.Start
+	if b.Start.After(start) {
+		start = b.Start
+	}
+	end := a.Start.Add(time.Duration(len(a.Counts)) * a.Unit)
+	if bEnd := b.Start.Add(time.Duration(len(b.Counts)) * b.Unit); bEnd.Before(end) {
+		end = bEnd
+	}
+	if !end.After(start) {
+		return nil, nil
+	}
+
+	n := int(end.Sub(start) / a.Unit)
+	ai := int(start.Sub(a.Start) / a.Unit)
+	bi := int(start.Sub(b.Start) / b.Unit)
+	return a.Counts[ai : ai+n], b.Counts[bi : bi+n]
+}
+
+// pearson returns the Pearson correlation coefficient between x[i] and
+// y[i+lag], for all the indices where both are defined
+func pearson(x, y []uint32, lag int) float64 {
+	var n, sx, sy, sxx, syy, sxy float64
+	for i := range x {
+		j := i + lag
+		if j < 0 || j >= len(y) {
+			continue
+		}
+		xi, yj := float64(x[i]), float64(y[j])
+		n++
+		sx += xi
+		sy += yj
+		sxx += xi * xi
+		syy += yj * yj
+		sxy += xi * yj
+	}
+	if n < 2 {
+		return 0
+	}
+
+	cov := sxy - sx*sy/n
+	vx := sxx - sx*sx/n
+	vy := syy - sy*sy/n
+	if vx <= 0 || vy <= 0 {
+		return 0
+	}
+	return cov / math.Sqrt(vx*vy)
+}
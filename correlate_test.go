@@ -0,0 +1,83 @@
+package hops
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCorrelate(t *testing.T) {
+	newCounter := func(counts ...uint32) *Counter {
+		c := NewCounter(len(counts), time.Hour)
+		c.prevCounts = counts[:len(counts)-1]
+		c.crtCount = counts[len(counts)-1]
+		return c
+	}
+
+	tests := map[string]struct {
+		a, b     *Counter
+		maxLag   int
+		wantCoef float64
+		wantLag  int
+	}{
+		"identical": {
+			newCounter(1, 5, 2, 8, 3),
+			newCounter(1, 5, 2, 8, 3),
+			0, 1, 0,
+		},
+		"opposite": {
+			newCounter(1, 2, 3, 4, 5),
+			newCounter(5, 4, 3, 2, 1),
+			0, -1, 0,
+		},
+		"b_follows_a_by_one_unit": {
+			newCounter(0, 9, 0, 0, 0, 4, 0),
+			newCounter(0, 0, 9, 0, 0, 0, 4),
+			2, -169.0 / 510, 1,
+		},
+		"no_events": {
+			newCounter(0, 0, 0),
+			newCounter(1, 2, 3),
+			1, 0, 0,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			corr, err := Correlate(tt.a, tt.b, tt.maxLag)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(corr.Coefficient-tt.wantCoef) > 1e-9 {
+				t.Errorf("expected coefficient: %v, got: %v", tt.wantCoef, corr.Coefficient)
+			}
+			if corr.Lag != tt.wantLag {
+				t.Errorf("expected lag: %d, got: %d", tt.wantLag, corr.Lag)
+			}
+		})
+	}
+}
+
+func TestCorrelateDifferentUnits(t *testing.T) {
+	_, err := Correlate(NewCounter(5, time.Second), NewCounter(5, time.Minute), 0)
+	if !errors.Is(err, ErrIncompatibleWindow) {
+		t.Errorf("expected: %v, got: %v", ErrIncompatibleWindow, err)
+	}
+}
+
+func TestAlignSnapshots(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 0, 0, 0, time.UTC)
+	a := Snapshot{Start: start, Unit: time.Minute, Counts: []uint32{1, 2, 3, 4, 5}}
+	b := Snapshot{Start: start.Add(2 * time.Minute), Unit: time.Minute, Counts: []uint32{6, 7}}
+
+	x, y := alignSnapshots(a, b)
+
+	if want := []uint32{3, 4}; !reflect.DeepEqual(x, want) {
+		t.Errorf("expected: %v, got: %v", want, x)
+	}
+	if want := []uint32{6, 7}; !reflect.DeepEqual(y, want) {
+		t.Errorf("expected: %v, got: %v", want, y)
+	}
+}
@@ -15,6 +15,15 @@ type Counter struct {
 	// Use only atomic operations to read and write to this field.
 	crtCount uint32
 
+	// Unix time, in nanoseconds, of the most recent event
+	lastObserved atomic.Int64
+
+	// Time unit and number of events of a single-unit window, packed in
+	// one word (see packed.go). Used instead of the fields below when
+	// isPacked is set.
+	packed   atomic.Uint64
+	isPacked bool
+
 	// Guards prevCounts and windowStart
 	mu sync.RWMutex
 
@@ -29,6 +38,28 @@ type Counter struct {
 
 	windowStart time.Time
 
+	// Time instant when the counter was created
+	created time.Time
+
+	// Set for counters that are advanced explicitly through Tick
+	manual bool
+
+	// Latest time instant passed to Tick. Guarded by mu.
+	tickTime time.Time
+
+	// Closed and replaced every time Tick moves the window. Guarded by mu.
+	ticked chan struct{}
+
+	// Idle checks that run on every Tick. Guarded by mu.
+	idleWatchers []*idleWatcher
+
+	// Ring of exemplars, one slot for each time unit of the window, or nil
+	// if none was observed. Guarded by mu.
+	exemplars []exemplarSlot
+
+	// Detection and handling of wall clock jumps
+	skew skewState
+
 	WindowSize time.Duration
 	Unit       time.Duration
 }
@@ -38,18 +69,39 @@ type Counter struct {
 // For example, NewCounter(5, time.Minute) creates a counter that keeps track
 // of how many events happened in the last 5 minutes.
 func NewCounter(windowSize int, timeUnit time.Duration) *Counter {
+	return newCounter(windowSize, timeUnit, time.Now())
+}
+
+// NewManualCounter creates a counter that doesn't follow the wall clock.
+// Its window starts at the given time instant and only moves forward when
+// the application calls Tick.
+//
+// It's meant for environments where background goroutines and timers are
+// undesirable, such as GOOS=js/wasm, and for deterministic tests.
+func NewManualCounter(windowSize int, timeUnit time.Duration, now time.Time) *Counter {
+	c := newCounter(windowSize, timeUnit, now)
+	c.isPacked = false
+	c.manual = true
+	c.tickTime = now
+	c.ticked = make(chan struct{})
+	return c
+}
+
+func newCounter(windowSize int, timeUnit time.Duration, now time.Time) *Counter {
 	// Initialize the window such that its end is on the current time unit.
 	//
 	// For example, if you create a 5-minute window at 15:21:43, then the
 	// window start will be at 15:17 and the window end at 15:21. The window
 	// covers events between 15:17:00 and 15:21:59.
-	windowStart := time.Now().Truncate(timeUnit).Add(timeUnit)
+	windowStart := now.Truncate(timeUnit).Add(timeUnit)
 	windowStart = windowStart.Add(-1 * time.Duration(windowSize) * timeUnit)
 
 	return &Counter{
 		crtCount:    0,
 		prevCounts:  make([]uint32, windowSize-1),
 		windowStart: windowStart,
+		created:     now,
+		isPacked:    windowSize == 1,
 		WindowSize:  time.Duration(windowSize) * timeUnit,
 		Unit:        timeUnit,
 	}
@@ -57,12 +109,63 @@ func NewCounter(windowSize int, timeUnit time.Duration) *Counter {
 
 // Observe adds an event to the window at the current moment in time
 func (c *Counter) Observe() {
-	c.refreshWindow()
-	atomic.AddUint32(&c.crtCount, 1)
+	var now time.Time
+	if c.isPacked {
+		now = c.now()
+		c.observePacked(now)
+	} else {
+		now = c.refreshWindow()
+		atomic.AddUint32(&c.crtCount, 1)
+	}
+	c.lastObserved.Store(now.UnixNano())
+}
+
+// LastObserved returns the time instant of the most recent event, or the
+// zero time if no event was observed yet.
+//
+// Unlike Value, it isn't limited to the window, so it can tell apart
+// "no events in the last 5 minutes" from "no events since yesterday".
+func (c *Counter) LastObserved() time.Time {
+	nanos := c.lastObserved.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+// TimeSinceLast returns how much time passed since the most recent event.
+// If no event was observed yet, it returns how much time passed since the
+// counter was created.
+func (c *Counter) TimeSinceLast() time.Duration {
+	last := c.LastObserved()
+	if last.IsZero() {
+		last = c.created
+	}
+	return c.now().Sub(last)
+}
+
+// AnyInLast reports whether at least one event happened in the last d,
+// e.g. to tell if an operation failed recently.
+//
+// It relies on the time of the most recent event, so d may be longer than
+// the window and isn't rounded to time units.
+func (c *Counter) AnyInLast(d time.Duration) bool {
+	last := c.LastObserved()
+	return !last.IsZero() && c.now().Sub(last) <= d
+}
+
+// NoneInLast reports whether no event happened in the last d, e.g. to
+// debounce an action until things calm down. It's the opposite of AnyInLast.
+func (c *Counter) NoneInLast(d time.Duration) bool {
+	return !c.AnyInLast(d)
 }
 
 // Value returns the number of events within the window
 func (c *Counter) Value() int {
+	if c.isPacked {
+		return int(c.packedCount(c.now()))
+	}
+
 	c.refreshWindow()
 
 	sum := atomic.LoadUint32(&c.crtCount)
@@ -75,10 +178,78 @@ func (c *Counter) Value() int {
 	return int(sum)
 }
 
+// EstimatedValue approximates the number of events within a sliding window
+// that ends at the current moment in time, rather than at the end of the
+// current time unit. Unlike Value, it doesn't jump when the window hops.
+//
+// The oldest time unit of the window is only partially covered by such a
+// sliding window, so its events are weighted by the part of it that is
+// still covered, assuming they're spread evenly across it.
+func (c *Counter) EstimatedValue() float64 {
+	s := c.Snapshot()
+	if len(s.Counts) < 2 {
+		return float64(s.Total)
+	}
+
+	now := c.now()
+	elapsed := float64(now.Sub(now.Truncate(c.Unit))) / float64(c.Unit)
+	return float64(s.Total) - float64(s.Counts[0])*elapsed
+}
+
+// Tick advances a manual counter to the given time instant, moving its
+// window forward if needed. Time instants older than the latest one passed
+// to Tick are ignored.
+//
+// Tick has no effect on counters that aren't created by NewManualCounter.
+func (c *Counter) Tick(now time.Time) {
+	if !c.manual {
+		return
+	}
+
+	c.mu.Lock()
+	if now.Before(c.tickTime) {
+		c.mu.Unlock()
+		return
+	}
+	c.tickTime = now
+	windowStart := c.windowStart
+	c.mu.Unlock()
+
+	c.moveWindow(now)
+
+	c.mu.Lock()
+	if !c.windowStart.Equal(windowStart) {
+		// Wake up everyone waiting for the window to hop
+		close(c.ticked)
+		c.ticked = make(chan struct{})
+	}
+	watchers := append([]*idleWatcher(nil), c.idleWatchers...)
+	c.mu.Unlock()
+
+	for _, w := range watchers {
+		w.check()
+	}
+}
+
+// now returns the current time instant as seen by the counter
+func (c *Counter) now() time.Time {
+	if !c.manual {
+		now := time.Now()
+		return c.skewTime(now, wallDrift(c.created, now))
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tickTime
+}
+
 // refreshWindow ensures the end of the window is on the current time unit
-func (c *Counter) refreshWindow() {
+// and returns the current time instant
+func (c *Counter) refreshWindow() time.Time {
+	crtTime := c.now()
+
 	// Truncate current timestamp to match the counter's time unit
-	now := time.Now().Truncate(c.Unit)
+	now := crtTime.Truncate(c.Unit)
 
 	c.mu.RLock()
 	isCurrentUnitInWindow := now.Sub(c.windowStart) < c.WindowSize
@@ -87,18 +258,23 @@ func (c *Counter) refreshWindow() {
 	if !isCurrentUnitInWindow {
 		c.moveWindow(now)
 	}
+	return crtTime
 }
 
 // moveWindow moves the window such that its end is on the given time instant
 // and removes the counts that fall outside of the window
 func (c *Counter) moveWindow(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.moveWindowLocked(t)
+}
+
+// moveWindowLocked is like moveWindow, but must be called with mu held
+func (c *Counter) moveWindowLocked(t time.Time) {
 	// Round the time instant to the next multiple of time unit such that
 	// the window will include this time instant as well
 	t = t.Truncate(c.Unit).Add(c.Unit)
 
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
 	// Do nothing if the window already covers the given time instant
 	if t.Sub(c.windowStart) <= c.WindowSize {
 		return
@@ -127,7 +303,7 @@ func (c *Counter) moveWindow(t time.Time) {
 // Example:
 //   INPUT:  s=[1, 2, 3, 4, 5]; p=2
 //   OUTPUT: s=[3, 4, 5, 0, 0]
-func leftShiftInPlace(s []uint32, p int) {
+func leftShiftInPlace[T Number](s []T, p int) {
 	if p <= 0 {
 		return
 	}
@@ -1,6 +1,7 @@
 package hops
 
 import (
+	"math"
 	"reflect"
 	"testing"
 	"time"
@@ -122,3 +123,145 @@ func TestLeftShiftInPlace(t *testing.T) {
 		})
 	}
 }
+
+func TestManualCounter(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+	c := NewManualCounter(3, time.Second, start)
+
+	c.Observe()
+	c.Observe()
+
+	// Time doesn't move on its own
+	if got := c.Value(); got != 2 {
+		t.Errorf("expected: 2, got: %d", got)
+	}
+
+	c.Tick(start.Add(time.Second))
+	c.Observe()
+	if got := c.Value(); got != 3 {
+		t.Errorf("expected: 3, got: %d", got)
+	}
+
+	// Ticking backwards is ignored
+	c.Tick(start)
+	if got := c.Value(); got != 3 {
+		t.Errorf("expected: 3, got: %d", got)
+	}
+
+	// The first two events fall outside of the window
+	c.Tick(start.Add(3 * time.Second))
+	if got := c.Value(); got != 1 {
+		t.Errorf("expected: 1, got: %d", got)
+	}
+
+	c.Tick(start.Add(10 * time.Second))
+	if got := c.Value(); got != 0 {
+		t.Errorf("expected: 0, got: %d", got)
+	}
+}
+
+func TestTickIgnoredByAutomaticCounter(t *testing.T) {
+	c := NewCounter(3, time.Hour)
+	windowStart := c.windowStart
+
+	c.Tick(time.Now().Add(24 * time.Hour))
+
+	if !c.windowStart.Equal(windowStart) {
+		t.Errorf("Tick moved the window of an automatic counter")
+	}
+}
+
+func TestLastObserved(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+	c := NewManualCounter(5, time.Second, start)
+
+	if !c.LastObserved().IsZero() {
+		t.Errorf("expected zero time before any event, got: %v", c.LastObserved())
+	}
+
+	c.Tick(start.Add(3 * time.Second))
+	if got := c.TimeSinceLast(); got != 3*time.Second {
+		t.Errorf("expected time since creation: 3s, got: %v", got)
+	}
+
+	c.Observe()
+	if got := c.LastObserved(); !got.Equal(start.Add(3 * time.Second)) {
+		t.Errorf("expected: %v, got: %v", start.Add(3*time.Second), got)
+	}
+
+	// Still reported after the event falls outside of the window
+	c.Tick(start.Add(40 * time.Second))
+	if got := c.TimeSinceLast(); got != 37*time.Second {
+		t.Errorf("expected: 37s, got: %v", got)
+	}
+	if c.Value() != 0 {
+		t.Errorf("expected the event to be outside of the window")
+	}
+}
+
+func TestAnyInLast(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+	c := NewManualCounter(5, time.Second, start)
+
+	if c.AnyInLast(time.Hour) || !c.NoneInLast(time.Hour) {
+		t.Error("expected no events before any event was observed")
+	}
+
+	c.Tick(start.Add(1500 * time.Millisecond))
+	c.Observe()
+	c.Tick(start.Add(10 * time.Second))
+
+	tests := map[string]struct {
+		d    time.Duration
+		want bool
+	}{
+		"shorter":       {8 * time.Second, false},
+		"exact":         {8500 * time.Millisecond, true},
+		"beyond_window": {time.Minute, true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := c.AnyInLast(tt.d); got != tt.want {
+				t.Errorf("expected: %v, got: %v", tt.want, got)
+			}
+			if got := c.NoneInLast(tt.d); got == tt.want {
+				t.Errorf("expected NoneInLast: %v, got: %v", !tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEstimatedValue(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	c := NewManualCounter(3, time.Second, start)
+	for i := 0; i < 10; i++ {
+		c.Observe()
+	}
+	c.Tick(start.Add(2 * time.Second))
+	c.Observe()
+	c.Observe()
+
+	// In order, since Tick can't go back in time
+	tests := []struct {
+		elapsed time.Duration
+		want    float64
+	}{
+		{0, 12},
+		{250 * time.Millisecond, 9.5},
+		{900 * time.Millisecond, 3},
+	}
+
+	for _, tt := range tests {
+		c.Tick(start.Add(2*time.Second + tt.elapsed))
+		if got := c.EstimatedValue(); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%v into the unit: expected: %v, got: %v", tt.elapsed, tt.want, got)
+		}
+	}
+
+	// The oldest events fall out of the window
+	c.Tick(start.Add(3 * time.Second))
+	if got := c.EstimatedValue(); got != 2 {
+		t.Errorf("expected: 2, got: %v", got)
+	}
+}
@@ -0,0 +1,94 @@
+package hops
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Crossing records a counter going past a threshold, or coming back from it
+type Crossing struct {
+	Time time.Time `json:"time"`
+
+	// Name of the counter
+	Counter string `json:"counter"`
+
+	// Number of events within the window of the counter
+	Value int `json:"value"`
+
+	Threshold int `json:"threshold"`
+
+	// Set when the counter recovered, rather than crossed the threshold
+	Recovered bool `json:"recovered"`
+
+	// Number of events in each time unit of the window, oldest first
+	Counts []uint32 `json:"counts,omitempty"`
+}
+
+// CrossingRecorder is implemented by the destinations of threshold
+// crossings, such as CrossingLog and Webhook
+type CrossingRecorder interface {
+	Record(c Crossing)
+}
+
+// CrossingLog keeps the most recent threshold crossings and recoveries in
+// memory, so post-incident reviews can reconstruct what fired and when.
+//
+// It's also an HTTP handler that serves the crossings as a JSON array,
+// oldest first:
+//
+//	http.Handle("/debug/hops/crossings", log)
+//
+// It's safe to use the log concurrently.
+type CrossingLog struct {
+	// Guards entries and next
+	mu sync.Mutex
+
+	// Ring buffer of crossings
+	entries []Crossing
+
+	// Index of the next entry to overwrite, once the buffer is full
+	next int
+
+	size int
+}
+
+// NewCrossingLog creates a log that keeps the given number of most recent
+// crossings
+func NewCrossingLog(size int) *CrossingLog {
+	return &CrossingLog{entries: make([]Crossing, 0, size), size: size}
+}
+
+// Record adds a crossing to the log, dropping the oldest one if the log
+// is full
+func (l *CrossingLog) Record(c Crossing) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.size <= 0 {
+		return
+	}
+	if len(l.entries) < l.size {
+		l.entries = append(l.entries, c)
+		return
+	}
+	l.entries[l.next] = c
+	l.next = (l.next + 1) % l.size
+}
+
+// Entries returns the crossings in the log, oldest first
+func (l *CrossingLog) Entries() []Crossing {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := make([]Crossing, 0, len(l.entries))
+	entries = append(entries, l.entries[l.next:]...)
+	return append(entries, l.entries[:l.next]...)
+}
+
+// ServeHTTP responds with the crossings in the log, as a JSON array
+func (l *CrossingLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(l.Entries())
+}
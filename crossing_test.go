@@ -0,0 +1,66 @@
+package hops_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestCrossingLog(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+	crossing := func(i int) hops.Crossing {
+		return hops.Crossing{Time: start.Add(time.Duration(i) * time.Second), Counter: "jobs", Value: i}
+	}
+
+	tests := map[string]struct {
+		size     int
+		recorded int
+		want     []hops.Crossing
+	}{
+		"empty":    {3, 0, []hops.Crossing{}},
+		"not_full": {3, 2, []hops.Crossing{crossing(0), crossing(1)}},
+		"wrapped":  {3, 5, []hops.Crossing{crossing(2), crossing(3), crossing(4)}},
+		"disabled": {0, 2, []hops.Crossing{}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			log := hops.NewCrossingLog(tt.size)
+			for i := 0; i < tt.recorded; i++ {
+				log.Record(crossing(i))
+			}
+
+			if got := log.Entries(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCrossingLogHandler(t *testing.T) {
+	log := hops.NewCrossingLog(10)
+	want := []hops.Crossing{{
+		Time:      time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC),
+		Counter:   "jobs",
+		Value:     3,
+		Threshold: 5,
+		Recovered: true,
+	}}
+	log.Record(want[0])
+
+	rec := httptest.NewRecorder()
+	log.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var got []hops.Crossing
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected: %v, got: %v", want, got)
+	}
+}
@@ -0,0 +1,104 @@
+package hops
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+//go:embed dashboard.html
+var dashboardPage []byte
+
+// dashboardEvent is the payload of an event streamed to the dashboard
+type dashboardEvent struct {
+	Time   time.Time      `json:"time"`
+	Values map[string]int `json:"values"`
+
+	// Most recent sample of each gauge with samples within its window
+	Gauges map[string]float64 `json:"gauges,omitempty"`
+}
+
+// NewDashboard returns an HTTP handler that serves a self-contained web page
+// charting the values of all counters and gauges from r, live. Gauges are
+// charted by their most recent sample.
+//
+// The handler serves the page on any path, and the stream of values that
+// feeds it, as Server-Sent Events, on paths ending in "/events". Mount it
+// on a path ending in a slash:
+//
+//	http.Handle("/debug/hops/", hops.NewDashboard(registry))
+//
+// Values are sent once per second. Add the "interval" query parameter to
+// the page URL to change that, e.g. /debug/hops/?interval=5s. Intervals
+// shorter than 100ms are rejected, as with NewSSEHandler.
+func NewDashboard(r *Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasSuffix(req.URL.Path, "/events") {
+			serveDashboardEvents(w, req, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(dashboardPage)
+	})
+}
+
+// serveDashboardEvents streams the values of all counters and gauges from r
+func serveDashboardEvents(w http.ResponseWriter, req *http.Request, r *Registry) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	interval := time.Second
+	if s := req.URL.Query().Get("interval"); s != "" {
+		d, err := parseInterval(s)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		interval = d
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		event := dashboardEvent{Time: time.Now(), Values: make(map[string]int)}
+		for _, name := range r.Names() {
+			if c := r.Get(name); c != nil {
+				event.Values[name] = c.Value()
+			}
+		}
+		for _, name := range r.GaugeNames() {
+			if g := r.Gauge(name); g != nil {
+				if stats := g.Stats(); stats.Count > 0 {
+					if event.Gauges == nil {
+						event.Gauges = make(map[string]float64)
+					}
+					event.Gauges[name] = stats.Last
+				}
+			}
+		}
+
+		data, _ := json.Marshal(event)
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+			return
+		}
+		flusher.Flush()
+
+		select {
+		case <-req.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
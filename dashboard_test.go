@@ -0,0 +1,99 @@
+package hops_test
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestDashboard(t *testing.T) {
+	r := hops.NewRegistry()
+	c := hops.NewCounter(5, time.Minute)
+	c.Observe()
+	r.MustRegister("jobs", c)
+
+	mux := http.NewServeMux()
+	mux.Handle("/debug/hops/", hops.NewDashboard(r))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	t.Run("page", func(t *testing.T) {
+		resp, err := http.Get(srv.URL + "/debug/hops/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		if !strings.Contains(string(body), "EventSource") {
+			t.Errorf("expected the dashboard page, got: %s", body)
+		}
+	})
+
+	t.Run("events", func(t *testing.T) {
+		resp, err := http.Get(srv.URL + "/debug/hops/events")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		line, err := bufio.NewReader(resp.Body).ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(line, `"values":{"jobs":1}`) {
+			t.Errorf("unexpected event: %q", line)
+		}
+	})
+
+	t.Run("interval_too_short", func(t *testing.T) {
+		resp, err := http.Get(srv.URL + "/debug/hops/events?interval=10ms")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected status: %d, got: %d", http.StatusBadRequest, resp.StatusCode)
+		}
+	})
+}
+
+func TestDashboardGauges(t *testing.T) {
+	r := hops.NewRegistry()
+	stop, err := r.Poll("queue.size", time.Hour, func() float64 { return 2.5 })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	srv := httptest.NewServer(hops.NewDashboard(r))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(line, `"gauges":{"queue.size":2.5}`) {
+		t.Errorf("unexpected event: %q", line)
+	}
+
+	if got, err := r.Query("queue.size * 2"); err != nil || got != 5 {
+		t.Errorf("expected: 5, got: %v, %v", got, err)
+	}
+	if got := r.SnapshotAll().Gauges["queue.size"].Last; got != 2.5 {
+		t.Errorf("expected: 2.5, got: %v", got)
+	}
+}
@@ -0,0 +1,98 @@
+package hops
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"math"
+	"time"
+)
+
+// Operator combines the values of two counters into a derived value
+type Operator string
+
+// Operators supported by Derived
+const (
+	OpAdd Operator = "+"
+	OpSub Operator = "-"
+	OpMul Operator = "*"
+	OpDiv Operator = "/"
+)
+
+// Derived is a read-only value computed from the windows of two counters,
+// such as an error ratio or the net number of connections:
+//
+//	errorRatio, err := hops.NewDerived(failures, hops.OpDiv, requests)
+//
+// Both windows are snapshotted at once, as with Registry.SnapshotAll, and
+// lined up on the same time units before they're combined, so the value
+// isn't skewed by a window hopping between reading the two counters.
+//
+// It's safe to use it concurrently.
+type Derived struct {
+	a, b *Counter
+	op   Operator
+}
+
+// NewDerived creates a value computed as a op b.
+// It fails with ErrIncompatibleWindow if the counters don't share the same
+// window size and time unit, and with ErrInvalidConfig if the operator
+// isn't supported.
+func NewDerived(a *Counter, op Operator, b *Counter) (*Derived, error) {
+	if a.WindowSize != b.WindowSize || a.Unit != b.Unit {
+		return nil, fmt.Errorf("%w: %v/%v and %v/%v differ", ErrIncompatibleWindow,
+			a.WindowSize, a.Unit, b.WindowSize, b.Unit)
+	}
+	switch op {
+	case OpAdd, OpSub, OpMul, OpDiv:
+	default:
+		return nil, fmt.Errorf("%w: unknown operator %q", ErrInvalidConfig, op)
+	}
+
+	return &Derived{a: a, b: b, op: op}, nil
+}
+
+// Value returns the derived value at the current moment in time.
+// Dividing by zero results in NaN.
+func (d *Derived) Value() float64 {
+	s := snapshotCounters([]*Counter{d.a, d.b}, time.Now())
+	return d.apply(s[0], s[1])
+}
+
+// Hops returns an iterator over the derived values at the end of every
+// time unit, as with Counter.Hops, until ctx is done. For manual counters,
+// it follows the ticks of the first counter.
+func (d *Derived) Hops(ctx context.Context) iter.Seq[float64] {
+	return func(yield func(float64) bool) {
+		for range d.a.Hops(ctx) {
+			if !yield(d.Value()) {
+				return
+			}
+		}
+	}
+}
+
+// apply combines the time units covered by both snapshots
+func (d *Derived) apply(a, b Snapshot) float64 {
+	x, y := alignSnapshots(a, b)
+
+	var sa, sb float64
+	for i := range x {
+		sa += float64(x[i])
+		sb += float64(y[i])
+	}
+
+	switch d.op {
+	case OpAdd:
+		return sa + sb
+	case OpSub:
+		return sa - sb
+	case OpMul:
+		return sa * sb
+	default:
+		if sb == 0 {
+			return math.NaN()
+		}
+		return sa / sb
+	}
+}
@@ -0,0 +1,121 @@
+package hops_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestDerived(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+	failures := hops.NewManualCounter(3, time.Second, start)
+	requests := hops.NewManualCounter(3, time.Second, start)
+	for i := 0; i < 8; i++ {
+		requests.Observe()
+	}
+	failures.Observe()
+	failures.Observe()
+
+	tests := map[string]struct {
+		op   hops.Operator
+		want float64
+	}{
+		"add": {hops.OpAdd, 10},
+		"sub": {hops.OpSub, -6},
+		"mul": {hops.OpMul, 16},
+		"div": {hops.OpDiv, 0.25},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			d, err := hops.NewDerived(failures, tt.op, requests)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := d.Value(); got != tt.want {
+				t.Errorf("expected: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+
+	// The events fall out of the window, leaving nothing to divide by
+	failures.Tick(start.Add(time.Minute))
+	requests.Tick(start.Add(time.Minute))
+	d, _ := hops.NewDerived(failures, hops.OpDiv, requests)
+	if got := d.Value(); !math.IsNaN(got) {
+		t.Errorf("expected NaN, got: %v", got)
+	}
+}
+
+func TestDerivedLinesUpWindows(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+	a := hops.NewManualCounter(2, time.Second, start)
+	b := hops.NewManualCounter(2, time.Second, start)
+	a.Observe()
+	b.Observe()
+
+	// Only the first counter hops, so its oldest unit isn't covered by the
+	// window of the second one
+	a.Tick(start.Add(time.Second))
+	a.Observe()
+	b.Observe()
+
+	d, _ := hops.NewDerived(a, hops.OpSub, b)
+	if got := d.Value(); got != -1 {
+		t.Errorf("expected: -1, got: %v", got)
+	}
+}
+
+func TestDerivedHops(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+	a := hops.NewManualCounter(100, time.Second, start)
+	b := hops.NewManualCounter(100, time.Second, start)
+	a.Observe()
+	a.Observe()
+	b.Observe()
+
+	d, _ := hops.NewDerived(a, hops.OpAdd, b)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	values := make(chan float64)
+	go func() {
+		for v := range d.Hops(ctx) {
+			values <- v
+		}
+	}()
+
+	// Keep ticking until the iterator picks up a hop
+	now := start
+	for {
+		select {
+		case v := <-values:
+			if v != 3 {
+				t.Errorf("expected: 3, got: %v", v)
+			}
+			return
+		case <-ctx.Done():
+			t.Fatal("expected a value")
+		case <-time.After(10 * time.Millisecond):
+			now = now.Add(time.Second)
+			a.Tick(now)
+			b.Tick(now)
+		}
+	}
+}
+
+func TestDerivedIncompatible(t *testing.T) {
+	a := hops.NewCounter(5, time.Minute)
+
+	if _, err := hops.NewDerived(a, hops.OpAdd, hops.NewCounter(5, time.Second)); !errors.Is(err, hops.ErrIncompatibleWindow) {
+		t.Errorf("expected: %v, got: %v", hops.ErrIncompatibleWindow, err)
+	}
+	if _, err := hops.NewDerived(a, "%", hops.NewCounter(5, time.Minute)); !errors.Is(err, hops.ErrInvalidConfig) {
+		t.Errorf("expected: %v, got: %v", hops.ErrInvalidConfig, err)
+	}
+}
@@ -0,0 +1,57 @@
+package hops
+
+import (
+	"fmt"
+	"time"
+)
+
+// BucketDelta is the change in the number of events of one time unit
+// between two snapshots
+type BucketDelta struct {
+	// Start of the time unit
+	Start time.Time
+
+	// Number of events added to the time unit since the older snapshot
+	Delta int
+}
+
+// SnapshotDiff holds the increments between two snapshots of a counter
+type SnapshotDiff struct {
+	// Increments for each time unit of the newer snapshot, ordered from the
+	// oldest time unit to the current one
+	Buckets []BucketDelta
+
+	// Number of events observed between the two snapshots
+	Total int
+}
+
+// DiffSnapshots returns the increments between an older snapshot a and a
+// newer snapshot b of the same counter, matching time units by their start.
+//
+// It lets a monitoring agent that scrapes periodically compute the exact
+// number of events observed between two scrapes, rather than the change in
+// the window total, which also drops events that expired in the meantime.
+// The result is exact as long as scrapes are less than a window apart.
+//
+// It fails with ErrIncompatibleWindow if the snapshots have different time
+// units.
+func DiffSnapshots(a, b Snapshot) (SnapshotDiff, error) {
+	if a.Unit != b.Unit {
+		return SnapshotDiff{}, fmt.Errorf("%w: time units %v and %v differ", ErrIncompatibleWindow, a.Unit, b.Unit)
+	}
+
+	diff := SnapshotDiff{Buckets: make([]BucketDelta, len(b.Counts))}
+	for i, n := range b.Counts {
+		start := b.Start.Add(time.Duration(i) * b.Unit)
+		delta := int(n)
+
+		// Subtract what the older snapshot already saw in this time unit
+		if j := int(start.Sub(a.Start) / a.Unit); !start.Before(a.Start) && j < len(a.Counts) {
+			delta -= int(a.Counts[j])
+		}
+
+		diff.Buckets[i] = BucketDelta{Start: start, Delta: delta}
+		diff.Total += delta
+	}
+	return diff, nil
+}
@@ -0,0 +1,72 @@
+package hops_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 0, 0, 0, time.UTC)
+	minute := func(n int) time.Time { return start.Add(time.Duration(n) * time.Minute) }
+
+	tests := map[string]struct {
+		a, b       hops.Snapshot
+		wantDeltas []int
+		wantTotal  int
+	}{
+		"same_window": {
+			hops.Snapshot{Start: start, Unit: time.Minute, Counts: []uint32{1, 2, 3}},
+			hops.Snapshot{Start: start, Unit: time.Minute, Counts: []uint32{1, 2, 7}},
+			[]int{0, 0, 4},
+			4,
+		},
+		"window_hopped": {
+			hops.Snapshot{Start: start, Unit: time.Minute, Counts: []uint32{1, 2, 3}},
+			hops.Snapshot{Start: minute(2), Unit: time.Minute, Counts: []uint32{5, 6, 1}},
+			[]int{2, 6, 1},
+			9,
+		},
+		"no_overlap": {
+			hops.Snapshot{Start: start, Unit: time.Minute, Counts: []uint32{1, 2, 3}},
+			hops.Snapshot{Start: minute(10), Unit: time.Minute, Counts: []uint32{4, 5, 6}},
+			[]int{4, 5, 6},
+			15,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			diff, err := hops.DiffSnapshots(tt.a, tt.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			deltas := make([]int, len(diff.Buckets))
+			for i, b := range diff.Buckets {
+				deltas[i] = b.Delta
+				if want := tt.b.Start.Add(time.Duration(i) * time.Minute); !b.Start.Equal(want) {
+					t.Errorf("bucket %d: expected start: %v, got: %v", i, want, b.Start)
+				}
+			}
+			if !reflect.DeepEqual(deltas, tt.wantDeltas) {
+				t.Errorf("expected deltas: %v, got: %v", tt.wantDeltas, deltas)
+			}
+			if diff.Total != tt.wantTotal {
+				t.Errorf("expected total: %d, got: %d", tt.wantTotal, diff.Total)
+			}
+		})
+	}
+}
+
+func TestDiffSnapshotsDifferentUnits(t *testing.T) {
+	a := hops.Snapshot{Unit: time.Second}
+	b := hops.Snapshot{Unit: time.Minute}
+
+	if _, err := hops.DiffSnapshots(a, b); !errors.Is(err, hops.ErrIncompatibleWindow) {
+		t.Errorf("expected: %v, got: %v", hops.ErrIncompatibleWindow, err)
+	}
+}
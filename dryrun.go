@@ -0,0 +1,74 @@
+package hops
+
+import "sync"
+
+// Number of denied keys remembered in dry-run mode
+const dryRunSampleSize = 64
+
+// dryRunLog remembers the keys of the most recent would-be denials
+type dryRunLog struct {
+	mu sync.Mutex
+
+	// Ring of denied keys. next is the position of the next key.
+	keys []string
+	next int
+}
+
+// add remembers a denied key
+func (d *dryRunLog) add(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.keys) < dryRunSampleSize {
+		d.keys = append(d.keys, key)
+		return
+	}
+	d.keys[d.next] = key
+	d.next = (d.next + 1) % dryRunSampleSize
+}
+
+// sample returns the distinct denied keys, most recent first
+func (d *dryRunLog) sample() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	seen := make(map[string]bool)
+	var keys []string
+	for i := 1; i <= len(d.keys); i++ {
+		key := d.keys[(d.next-i+len(d.keys))%len(d.keys)]
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// SetDryRun turns dry-run mode on or off. In dry-run mode, the limiter
+// evaluates every event as usual but allows all of them. Events that would
+// have been denied are counted in Metrics().Denied, and their keys are
+// sampled in DeniedKeys, so safe limits can be derived from production
+// traffic before they are enforced.
+func (k *KeyedLimiter) SetDryRun(on bool) {
+	k.dryRun.Store(on)
+}
+
+// DeniedKeys returns a sample of the keys whose events would have been
+// denied in dry-run mode, most recent first. It holds up to 64 of the most
+// recent denials.
+func (k *KeyedLimiter) DeniedKeys() []string {
+	return k.denied.sample()
+}
+
+// decide records the decision of a limiter for the given key, and returns
+// whether the event may go through. Outside of dry-run mode, that's the
+// decision itself.
+func (k *KeyedLimiter) decide(key string, allowed bool) bool {
+	k.metrics.Load().record(key, allowed)
+	if allowed || !k.dryRun.Load() {
+		return allowed
+	}
+
+	k.denied.add(key)
+	return true
+}
@@ -0,0 +1,50 @@
+package hops_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestKeyedLimiterDryRun(t *testing.T) {
+	l := hops.NewKeyedLimiter(func() hops.Limiter {
+		return hops.NewWindowLimiter(hops.NewCounter(5, time.Minute), 1)
+	})
+	l.SetDryRun(true)
+
+	for _, key := range []string{"alice", "alice", "bob", "alice", "bob", "carol"} {
+		if !l.Allow(key) {
+			t.Errorf("expected all events to be allowed in dry-run mode")
+		}
+	}
+
+	if got := l.Metrics().Denied.Value(); got != 3 {
+		t.Errorf("expected would-be denials: 3, got: %d", got)
+	}
+	if want := []string{"bob", "alice"}; !reflect.DeepEqual(l.DeniedKeys(), want) {
+		t.Errorf("expected denied keys: %v, got: %v", want, l.DeniedKeys())
+	}
+
+	// Enforcement starts once dry-run mode is off
+	l.SetDryRun(false)
+	if l.Allow("alice") {
+		t.Errorf("expected events over the limit to be denied")
+	}
+}
+
+func TestHierarchicalLimiterDryRun(t *testing.T) {
+	global := hops.NewWindowLimiter(hops.NewCounter(5, time.Minute), 1)
+	keys := hops.NewKeyedLimiter(func() hops.Limiter {
+		return hops.NewWindowLimiter(hops.NewCounter(5, time.Minute), 5)
+	})
+	keys.SetDryRun(true)
+	l := hops.NewHierarchicalLimiter(global, keys)
+
+	l.Allow("alice")
+	allowed, level := l.Allow("bob")
+	if !allowed || level != hops.LevelGlobal {
+		t.Errorf("expected allowed with would-be level global, got: %v/%v", allowed, level)
+	}
+}
@@ -0,0 +1,27 @@
+package hops
+
+import "errors"
+
+// Errors returned by this package. They are wrapped with more details, so
+// check for them with errors.Is.
+var (
+	// ErrAlreadyRegistered is returned when registering a counter, gauge,
+	// limiter or alert rule under a name that is already taken
+	ErrAlreadyRegistered = errors.New("hops: name already registered")
+
+	// ErrIncompatibleWindow is returned when combining counters whose
+	// windows don't line up, e.g. because they use different time units
+	ErrIncompatibleWindow = errors.New("hops: incompatible windows")
+
+	// ErrInvalidConfig is returned when a configuration is incomplete or
+	// inconsistent
+	ErrInvalidConfig = errors.New("hops: invalid configuration")
+
+	// ErrLimitExceeded is returned when waiting for an event that the limit
+	// can never allow, e.g. because it's 0
+	ErrLimitExceeded = errors.New("hops: limit exceeded")
+
+	// ErrInvalidQuery is returned when a query expression is malformed or
+	// refers to unknown counters
+	ErrInvalidQuery = errors.New("hops: invalid query")
+)
@@ -0,0 +1,182 @@
+package hops
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Attr is an attribute of an event, such as its status or region
+type Attr struct {
+	Key   string
+	Value string
+}
+
+// Attrs is the set of attributes of an event, sorted by key
+type Attrs []Attr
+
+// Get returns the value of the attribute with the given key, or the empty
+// string if there is no such attribute
+func (a Attrs) Get(key string) string {
+	for _, attr := range a {
+		if attr.Key == key {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// Events is a hopping window counter of events tagged with attributes,
+// which can be counted by any combination of attributes after the fact.
+//
+// It's meant for ad-hoc slicing of a small number of attribute values,
+// without declaring a CounterVec for each way of looking at the events:
+//
+//	e := hops.NewEvents(5, time.Minute)
+//	e.Observe(hops.Attr{"status", "500"}, hops.Attr{"region", "eu"})
+//	failedInEU := e.Count(func(a hops.Attrs) bool {
+//		return a.Get("status") == "500" && a.Get("region") == "eu"
+//	})
+//
+// It takes memory proportional to the number of distinct attribute sets
+// in each time unit of the window.
+//
+// It's safe to use it concurrently.
+type Events struct {
+	// Guards slots and tickTime
+	mu sync.Mutex
+
+	// Events of each time unit of the window
+	slots *Ring[eventSlot]
+
+	// Set for counters that are advanced explicitly through Tick
+	manual bool
+
+	// Latest time instant passed to Tick
+	tickTime time.Time
+}
+
+// eventSlot holds the events of a time unit
+type eventSlot struct {
+	// Number of events of each attribute set, keyed by the encoded set
+	groups map[string]*eventGroup
+}
+
+type eventGroup struct {
+	attrs Attrs
+	count int
+}
+
+// NewEvents creates a counter of events with the given window size and
+// time unit
+func NewEvents(windowSize int, timeUnit time.Duration) *Events {
+	return &Events{slots: NewRing[eventSlot](windowSize, timeUnit)}
+}
+
+// NewManualEvents creates a counter of events that doesn't follow the wall
+// clock. It only moves forward when the application calls Tick.
+func NewManualEvents(windowSize int, timeUnit time.Duration, now time.Time) *Events {
+	e := NewEvents(windowSize, timeUnit)
+	e.manual = true
+	e.tickTime = now
+	return e
+}
+
+// Observe adds an event with the given attributes to the window at the
+// current moment in time. The order of the attributes doesn't matter.
+func (e *Events) Observe(attrs ...Attr) {
+	key := encodeAttrs(attrs)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	slot := e.slots.Bucket(e.now())
+	if slot == nil {
+		// The wall clock went back past the window
+		return
+	}
+	if slot.groups == nil {
+		slot.groups = make(map[string]*eventGroup)
+	}
+	g, ok := slot.groups[key]
+	if !ok {
+		sorted := slices.Clone(Attrs(attrs))
+		slices.SortFunc(sorted, func(a, b Attr) int { return strings.Compare(a.Key, b.Key) })
+		g = &eventGroup{attrs: sorted}
+		slot.groups[key] = g
+	}
+	g.count++
+}
+
+// Count returns the number of events within the window whose attributes
+// match, or of all events if match is nil
+func (e *Events) Count(match func(Attrs) bool) int {
+	n := 0
+	e.each(func(g *eventGroup) {
+		if match == nil || match(g.attrs) {
+			n += g.count
+		}
+	})
+	return n
+}
+
+// CountBy returns the number of events within the window for each value of
+// the attribute with the given key. Events without that attribute are
+// counted under the empty string.
+func (e *Events) CountBy(key string) map[string]int {
+	counts := make(map[string]int)
+	e.each(func(g *eventGroup) {
+		counts[g.attrs.Get(key)] += g.count
+	})
+	return counts
+}
+
+// Tick advances a manual counter to the given time instant. Time instants
+// older than the latest one passed to Tick are ignored.
+//
+// Tick has no effect on counters that aren't created by NewManualEvents.
+func (e *Events) Tick(now time.Time) {
+	if !e.manual {
+		return
+	}
+
+	e.mu.Lock()
+	if now.After(e.tickTime) {
+		e.tickTime = now
+	}
+	e.mu.Unlock()
+}
+
+// each calls fn with the events of every attribute set within the window.
+// fn is called with mu held.
+func (e *Events) each(fn func(g *eventGroup)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, slot := range e.slots.All(e.now()) {
+		for _, g := range slot.groups {
+			fn(g)
+		}
+	}
+}
+
+// now returns the current time instant as seen by the counter.
+// It must be called with mu held.
+func (e *Events) now() time.Time {
+	if !e.manual {
+		return time.Now()
+	}
+	return e.tickTime
+}
+
+// encodeAttrs returns a key that identifies the set of attributes, no
+// matter their order
+func encodeAttrs(attrs []Attr) string {
+	parts := make([]string, len(attrs))
+	for i, attr := range attrs {
+		parts[i] = attr.Key + "\xfe" + attr.Value
+	}
+	slices.Sort(parts)
+	return strings.Join(parts, "\xff")
+}
@@ -0,0 +1,58 @@
+package hops_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestEvents(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+	e := hops.NewManualEvents(3, time.Second, start)
+
+	e.Observe(hops.Attr{"status", "500"}, hops.Attr{"region", "eu"})
+	e.Tick(start.Add(time.Second))
+	e.Observe(hops.Attr{"region", "eu"}, hops.Attr{"status", "500"})
+	e.Observe(hops.Attr{"status", "200"}, hops.Attr{"region", "us"})
+	e.Tick(start.Add(2 * time.Second))
+	e.Observe(hops.Attr{"status", "200"}, hops.Attr{"region", "eu"})
+	e.Observe()
+
+	tests := map[string]struct {
+		match func(hops.Attrs) bool
+		want  int
+	}{
+		"all": {nil, 5},
+		"failed_in_eu": {func(a hops.Attrs) bool {
+			return a.Get("status") == "500" && a.Get("region") == "eu"
+		}, 2},
+		"us":                 {func(a hops.Attrs) bool { return a.Get("region") == "us" }, 1},
+		"without_attributes": {func(a hops.Attrs) bool { return len(a) == 0 }, 1},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := e.Count(tt.match); got != tt.want {
+				t.Errorf("expected: %d, got: %d", tt.want, got)
+			}
+		})
+	}
+
+	want := map[string]int{"eu": 3, "us": 1, "": 1}
+	if got := e.CountBy("region"); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected: %v, got: %v", want, got)
+	}
+
+	// The first time unit falls out of the window
+	e.Tick(start.Add(3 * time.Second))
+	if got := e.Count(nil); got != 4 {
+		t.Errorf("expected: 4, got: %d", got)
+	}
+
+	e.Tick(start.Add(time.Minute))
+	if got := e.Count(nil); got != 0 {
+		t.Errorf("expected: 0, got: %d", got)
+	}
+}
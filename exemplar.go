@@ -0,0 +1,75 @@
+package hops
+
+import "time"
+
+// Exemplar is an example of an event, such as the trace of a request, that
+// links a time unit of a window to the details of what happened in it
+type Exemplar struct {
+	TraceID string
+
+	// Additional attributes of the event, e.g. the span ID
+	Attributes map[string]string
+
+	// Time instant of the event
+	Time time.Time
+}
+
+// exemplarSlot holds the exemplar of a time unit
+type exemplarSlot struct {
+	// Start of the time unit
+	start time.Time
+
+	exemplar Exemplar
+}
+
+// ObserveExemplar adds an event to the window at the current moment in
+// time, like Observe, and keeps e as the exemplar of the current time unit,
+// replacing the previous one. Snapshots report the exemplar of each time
+// unit, so a spike in the window links directly to example traces.
+//
+// If e.Time is the zero time, it's set to the time of the event.
+func (c *Counter) ObserveExemplar(e Exemplar) {
+	c.Observe()
+
+	now := c.now()
+	if e.Time.IsZero() {
+		e.Time = now
+	}
+	start := now.Truncate(c.Unit)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Allocated on the first exemplar, so counters without exemplars
+	// don't pay for them
+	if c.exemplars == nil {
+		c.exemplars = make([]exemplarSlot, len(c.prevCounts)+1)
+	}
+	c.exemplars[c.exemplarIndex(start)] = exemplarSlot{start: start, exemplar: e}
+}
+
+// appendExemplars sets the exemplars of s from the ones of the counter.
+// It must be called with mu held, after the rest of s is set.
+func (c *Counter) appendExemplars(s *Snapshot) {
+	s.Exemplars = s.Exemplars[:0]
+	if c.exemplars == nil {
+		return
+	}
+
+	start := s.Start
+	for range s.Counts {
+		var e Exemplar
+		if slot := c.exemplars[c.exemplarIndex(start)]; slot.start.Equal(start) {
+			e = slot.exemplar
+		}
+		s.Exemplars = append(s.Exemplars, e)
+		start = start.Add(c.Unit)
+	}
+}
+
+// exemplarIndex returns the slot of the time unit that starts at the given
+// time instant
+func (c *Counter) exemplarIndex(start time.Time) int {
+	n := int64(len(c.exemplars))
+	return int((start.UnixNano()/int64(c.Unit)%n + n) % n)
+}
@@ -0,0 +1,46 @@
+package hops_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestObserveExemplar(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	c := hops.NewManualCounter(3, time.Second, start)
+
+	if s := c.Snapshot(); s.Exemplars != nil {
+		t.Errorf("expected no exemplars, got: %v", s.Exemplars)
+	}
+
+	c.ObserveExemplar(hops.Exemplar{TraceID: "a"})
+	c.ObserveExemplar(hops.Exemplar{TraceID: "b", Attributes: map[string]string{"span": "1"}})
+	c.Tick(start.Add(2 * time.Second))
+	at := start.Add(1500 * time.Millisecond)
+	c.ObserveExemplar(hops.Exemplar{TraceID: "c", Time: at})
+	c.Observe()
+
+	s := c.Snapshot()
+	want := []hops.Exemplar{
+		{TraceID: "b", Attributes: map[string]string{"span": "1"}, Time: start},
+		{},
+		{TraceID: "c", Time: at},
+	}
+	if !reflect.DeepEqual(s.Exemplars, want) {
+		t.Errorf("expected: %v, got: %v", want, s.Exemplars)
+	}
+	if s.Total != 4 {
+		t.Errorf("expected: 4, got: %d", s.Total)
+	}
+
+	// The exemplar of a time unit leaves along with its events, and isn't
+	// mistaken for the one of a later time unit that shares its slot
+	c.Tick(start.Add(3 * time.Second))
+	want = []hops.Exemplar{{}, {TraceID: "c", Time: at}, {}}
+	if got := c.Snapshot().Exemplars; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected: %v, got: %v", want, got)
+	}
+}
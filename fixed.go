@@ -0,0 +1,110 @@
+package hops
+
+import (
+	"sync"
+	"time"
+)
+
+// Buckets lists the arrays that can hold the counts of a FixedCounter.
+// The length of the array is the window size.
+type Buckets interface {
+	[1]uint32 | [2]uint32 | [3]uint32 | [4]uint32 | [5]uint32 |
+		[6]uint32 | [7]uint32 | [8]uint32 | [9]uint32 | [10]uint32 |
+		[12]uint32 | [15]uint32 | [20]uint32 | [24]uint32 | [30]uint32 |
+		[60]uint32
+}
+
+// FixedCounter is a hopping window counter that keeps its counts in a
+// fixed-size array instead of a slice, and tracks time as plain integers
+// instead of time.Time values. It never allocates after it's created,
+// which makes it suitable for microcontrollers and other constrained
+// environments (e.g. TinyGo).
+//
+// Unlike the other windowed types, it doesn't build on Ring, whose slots
+// are allocated at runtime and hold time.Time values.
+//
+// The window size is given by the array type. For example, a
+// FixedCounter[[5]uint32] with a time unit of one minute keeps track of
+// how many events happened in the last 5 minutes.
+//
+// It's safe to use this counter concurrently.
+type FixedCounter[B Buckets] struct {
+	mu sync.Mutex
+
+	// Ring of counts, one for each time unit of the window.
+	// counts[u % len(counts)] = number of events that happened in time unit u
+	counts B
+
+	// Most recent time unit that was observed or queried
+	crtUnit int64
+
+	// Duration of a time unit, in nanoseconds
+	unit int64
+}
+
+// NewFixedCounter creates a new fixed-size counter with the given time unit.
+//
+// For example, NewFixedCounter[[5]uint32](time.Minute) creates a counter
+// that keeps track of how many events happened in the last 5 minutes.
+func NewFixedCounter[B Buckets](timeUnit time.Duration) *FixedCounter[B] {
+	return &FixedCounter[B]{unit: int64(timeUnit)}
+}
+
+// Observe adds an event to the window at the current moment in time
+func (c *FixedCounter[B]) Observe() {
+	c.ObserveAt(time.Now().UnixNano())
+}
+
+// ObserveAt adds an event to the window at the given moment in time,
+// expressed in nanoseconds. Any monotonic source of nanoseconds works,
+// as long as the counter is always used with the same one.
+//
+// Events older than the window are ignored.
+func (c *FixedCounter[B]) ObserveAt(nanos int64) {
+	u := nanos / c.unit
+
+	c.mu.Lock()
+	c.advance(u)
+	if u > c.crtUnit-int64(len(c.counts)) {
+		c.counts[u%int64(len(c.counts))]++
+	}
+	c.mu.Unlock()
+}
+
+// Value returns the number of events within the window
+func (c *FixedCounter[B]) Value() int {
+	return c.ValueAt(time.Now().UnixNano())
+}
+
+// ValueAt returns the number of events within the window that ends at the
+// given moment in time, expressed in nanoseconds
+func (c *FixedCounter[B]) ValueAt(nanos int64) int {
+	c.mu.Lock()
+	c.advance(nanos / c.unit)
+	var sum uint32
+	for i := 0; i < len(c.counts); i++ {
+		sum += c.counts[i]
+	}
+	c.mu.Unlock()
+
+	return int(sum)
+}
+
+// advance moves the window such that its end is on time unit u and clears
+// the counts that fall outside of the window. Must be called with mu held.
+func (c *FixedCounter[B]) advance(u int64) {
+	if u <= c.crtUnit {
+		return
+	}
+
+	// Clear at most one full ring, no matter how much time has passed
+	n := int64(len(c.counts))
+	if u-c.crtUnit < n {
+		n = u - c.crtUnit
+	}
+	for i := int64(1); i <= n; i++ {
+		c.counts[(c.crtUnit+i)%int64(len(c.counts))] = 0
+	}
+
+	c.crtUnit = u
+}
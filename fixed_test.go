@@ -0,0 +1,74 @@
+package hops_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestFixedCounter(t *testing.T) {
+	sec := int64(time.Second)
+	start := 1000 * sec
+
+	tests := map[string]struct {
+		observations []int64
+		queryAt      int64
+		want         int
+	}{
+		"same_unit": {
+			[]int64{start, start + 1, start + 2},
+			start + 3,
+			3,
+		},
+		"spread_over_the_window": {
+			[]int64{start, start + sec, start + 2*sec},
+			start + 2*sec,
+			3,
+		},
+		"oldest_unit_expired": {
+			[]int64{start, start + sec, start + 2*sec},
+			start + 3*sec,
+			2,
+		},
+		"all_expired": {
+			[]int64{start, start + sec, start + 2*sec},
+			start + 100*sec,
+			0,
+		},
+		"late_observation_within_the_window": {
+			[]int64{start + 2*sec, start},
+			start + 2*sec,
+			2,
+		},
+		"late_observation_outside_of_the_window": {
+			[]int64{start + 3*sec, start},
+			start + 3*sec,
+			1,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := hops.NewFixedCounter[[3]uint32](time.Second)
+			for _, ts := range tt.observations {
+				c.ObserveAt(ts)
+			}
+			if got := c.ValueAt(tt.queryAt); got != tt.want {
+				t.Errorf("expected: %d, got: %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFixedCounterDoesNotAllocate(t *testing.T) {
+	c := hops.NewFixedCounter[[5]uint32](time.Second)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		c.Observe()
+		c.Value()
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations, got: %v", allocs)
+	}
+}
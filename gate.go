@@ -0,0 +1,122 @@
+package hops
+
+import (
+	"sync"
+	"time"
+)
+
+// Gate lets an action through at most once per key within a hopping window
+// of W time units.
+//
+// It's meant for suppressing duplicates, like sending an alert email at most
+// once every 10 minutes for each alert:
+//
+//	g := hops.NewGate(10, time.Minute)
+//	if g.TryAcquire(alert.Name) {
+//		sendEmail(alert)
+//	}
+//
+// It's safe to use the gate concurrently.
+type Gate struct {
+	// Guards all the fields below
+	mu sync.Mutex
+
+	// Time unit of the last successful TryAcquire for each key.
+	// Time units are counted from the Unix epoch.
+	acquired map[string]int64
+
+	// Time unit when the expired keys were last removed
+	lastSweep int64
+
+	// Set for gates that are advanced explicitly through Tick
+	manual bool
+
+	// Latest time instant passed to Tick
+	tickTime time.Time
+
+	windowSize int64
+	unit       time.Duration
+}
+
+// NewGate creates a new gate with the given window size and time unit.
+//
+// For example, NewGate(10, time.Minute) creates a gate that lets an action
+// through at most once every 10 minutes for each key.
+func NewGate(windowSize int, timeUnit time.Duration) *Gate {
+	return &Gate{
+		acquired:   make(map[string]int64),
+		windowSize: int64(windowSize),
+		unit:       timeUnit,
+	}
+}
+
+// NewManualGate creates a gate that doesn't follow the wall clock.
+// Time only moves forward when the application calls Tick.
+func NewManualGate(windowSize int, timeUnit time.Duration, now time.Time) *Gate {
+	g := NewGate(windowSize, timeUnit)
+	g.manual = true
+	g.tickTime = now
+	return g
+}
+
+// TryAcquire reports whether the action identified by key may happen now.
+// It returns true at most once per key within the window.
+func (g *Gate) TryAcquire(key string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	crtUnit := g.crtUnit()
+	g.sweep(crtUnit)
+
+	if last, ok := g.acquired[key]; ok && crtUnit-last < g.windowSize {
+		return false
+	}
+	g.acquired[key] = crtUnit
+	return true
+}
+
+// Reset lets the action identified by key through again, even if it was
+// already acquired within the window
+func (g *Gate) Reset(key string) {
+	g.mu.Lock()
+	delete(g.acquired, key)
+	g.mu.Unlock()
+}
+
+// Tick advances a manual gate to the given time instant. Time instants older
+// than the latest one passed to Tick are ignored.
+//
+// Tick has no effect on gates that aren't created by NewManualGate.
+func (g *Gate) Tick(now time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.manual && now.After(g.tickTime) {
+		g.tickTime = now
+	}
+}
+
+// crtUnit returns the current time unit. Must be called with mu held.
+func (g *Gate) crtUnit() int64 {
+	now := g.tickTime
+	if !g.manual {
+		now = time.Now()
+	}
+	return now.UnixNano() / int64(g.unit)
+}
+
+// sweep removes the keys whose window has passed, once per time unit, so
+// the gate doesn't grow with every key it has ever seen. Must be called
+// with mu held.
+func (g *Gate) sweep(crtUnit int64) {
+	if crtUnit == g.lastSweep {
+		return
+	}
+	g.lastSweep = crtUnit
+
+	for key, last := range g.acquired {
+		if crtUnit-last >= g.windowSize {
+			delete(g.acquired, key)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package hops_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestGate(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	g := hops.NewManualGate(10, time.Minute, start)
+
+	if !g.TryAcquire("disk-full") {
+		t.Errorf("expected the first acquire to succeed")
+	}
+	if g.TryAcquire("disk-full") {
+		t.Errorf("expected the second acquire within the window to fail")
+	}
+	if !g.TryAcquire("cpu-high") {
+		t.Errorf("expected keys to be independent")
+	}
+
+	g.Tick(start.Add(9 * time.Minute))
+	if g.TryAcquire("disk-full") {
+		t.Errorf("expected acquire to fail before the window passes")
+	}
+
+	g.Tick(start.Add(10 * time.Minute))
+	if !g.TryAcquire("disk-full") {
+		t.Errorf("expected acquire to succeed after the window passes")
+	}
+
+	g.Reset("disk-full")
+	if !g.TryAcquire("disk-full") {
+		t.Errorf("expected acquire to succeed after Reset")
+	}
+}
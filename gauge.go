@@ -0,0 +1,186 @@
+package hops
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Gauge is a hopping window of samples of a value that goes up and down,
+// such as the number of goroutines or the size of a queue. It reports
+// statistics of the samples within the window, e.g. the peak heap size of
+// the last 5 minutes.
+//
+// It's safe to use the gauge concurrently.
+type Gauge struct {
+	// Guards slots and tickTime
+	mu sync.Mutex
+
+	// Statistics of the samples of each time unit of the window
+	slots *Ring[gaugeSlot]
+
+	// Set for gauges that are advanced explicitly through Tick
+	manual bool
+
+	// Latest time instant passed to Tick
+	tickTime time.Time
+}
+
+// gaugeSlot holds the statistics of the samples of a time unit
+type gaugeSlot struct {
+	// Statistics of the samples, valid only if count > 0
+	count         int
+	sum, min, max float64
+	last          float64
+}
+
+// GaugeStats holds statistics of the samples of a gauge within its window
+type GaugeStats struct {
+	// Number of samples
+	Count int
+
+	// Most recent sample
+	Last float64
+
+	Min  float64
+	Max  float64
+	Mean float64
+}
+
+// NewGauge creates a gauge with the given window size and time unit
+func NewGauge(windowSize int, timeUnit time.Duration) *Gauge {
+	return &Gauge{slots: NewRing[gaugeSlot](windowSize, timeUnit)}
+}
+
+// NewManualGauge creates a gauge that doesn't follow the wall clock.
+// It only moves forward when the application calls Tick.
+func NewManualGauge(windowSize int, timeUnit time.Duration, now time.Time) *Gauge {
+	g := NewGauge(windowSize, timeUnit)
+	g.manual = true
+	g.tickTime = now
+	return g
+}
+
+// Set adds a sample of the value to the window at the current moment in
+// time
+func (g *Gauge) Set(v float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	slot := g.slots.Bucket(g.now())
+	if slot == nil {
+		// The wall clock went back past the window
+		return
+	}
+	if slot.count == 0 {
+		*slot = gaugeSlot{min: v, max: v}
+	}
+	slot.count++
+	slot.sum += v
+	slot.min = math.Min(slot.min, v)
+	slot.max = math.Max(slot.max, v)
+	slot.last = v
+}
+
+// Stats returns statistics of the samples within the window. All of them
+// are 0 if there are no samples.
+func (g *Gauge) Stats() GaugeStats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var stats GaugeStats
+	var sum float64
+
+	for _, slot := range g.slots.All(g.now()) {
+		if slot.count == 0 {
+			continue
+		}
+		if stats.Count == 0 {
+			stats.Min, stats.Max = slot.min, slot.max
+		}
+		stats.Count += slot.count
+		sum += slot.sum
+		stats.Min = math.Min(stats.Min, slot.min)
+		stats.Max = math.Max(stats.Max, slot.max)
+		stats.Last = slot.last
+	}
+
+	if stats.Count > 0 {
+		stats.Mean = sum / float64(stats.Count)
+	}
+	return stats
+}
+
+// Tick advances a manual gauge to the given time instant. Time instants
+// older than the latest one passed to Tick are ignored.
+//
+// Tick has no effect on gauges that aren't created by NewManualGauge.
+func (g *Gauge) Tick(now time.Time) {
+	if !g.manual {
+		return
+	}
+
+	g.mu.Lock()
+	if now.After(g.tickTime) {
+		g.tickTime = now
+	}
+	g.mu.Unlock()
+}
+
+// now returns the current time instant as seen by the gauge.
+// It must be called with mu held.
+func (g *Gauge) now() time.Time {
+	if !g.manual {
+		return time.Now()
+	}
+	return g.tickTime
+}
+
+// RegisterGauge adds g to the registry under the given name. Gauges share
+// the namespace of counters, but aren't returned by Get and Names.
+// It fails with ErrAlreadyRegistered if a counter or another gauge is
+// already registered under the same name.
+func (r *Registry) RegisterGauge(name string, g *Gauge) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.counters[name]; ok {
+		return fmt.Errorf("%w: %q", ErrAlreadyRegistered, name)
+	}
+	if _, ok := r.gauges[name]; ok {
+		return fmt.Errorf("%w: %q", ErrAlreadyRegistered, name)
+	}
+	r.gauges[name] = g
+	return nil
+}
+
+// MustRegisterGauge is like RegisterGauge but panics if the name is
+// already taken
+func (r *Registry) MustRegisterGauge(name string, g *Gauge) {
+	if err := r.RegisterGauge(name, g); err != nil {
+		panic(err)
+	}
+}
+
+// Gauge returns the gauge registered under the given name, or nil if there
+// is no such gauge
+func (r *Registry) Gauge(name string) *Gauge {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.gauges[name]
+}
+
+// GaugeNames returns the names of all registered gauges, in sorted order
+func (r *Registry) GaugeNames() []string {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.gauges))
+	for name := range r.gauges {
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,59 @@
+package hops_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestGauge(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	g := hops.NewManualGauge(3, time.Second, start)
+
+	if got := g.Stats(); got != (hops.GaugeStats{}) {
+		t.Errorf("expected no statistics without samples, got: %+v", got)
+	}
+
+	g.Set(10)
+	g.Set(2)
+	g.Tick(start.Add(time.Second))
+	g.Set(6)
+	g.Tick(start.Add(2 * time.Second))
+	g.Set(4)
+
+	want := hops.GaugeStats{Count: 4, Last: 4, Min: 2, Max: 10, Mean: 5.5}
+	if got := g.Stats(); got != want {
+		t.Errorf("expected: %+v, got: %+v", want, got)
+	}
+
+	// The first samples fall out of the window
+	g.Tick(start.Add(3 * time.Second))
+	want = hops.GaugeStats{Count: 2, Last: 4, Min: 4, Max: 6, Mean: 5}
+	if got := g.Stats(); got != want {
+		t.Errorf("expected: %+v, got: %+v", want, got)
+	}
+}
+
+func TestRegistryGauges(t *testing.T) {
+	r := hops.NewRegistry()
+	g := hops.NewGauge(5, time.Minute)
+	r.MustRegisterGauge("queue.size", g)
+	r.MustRegister("jobs", hops.NewCounter(5, time.Minute))
+
+	if err := r.Register("queue.size", hops.NewCounter(5, time.Minute)); !errors.Is(err, hops.ErrAlreadyRegistered) {
+		t.Errorf("expected: %v, got: %v", hops.ErrAlreadyRegistered, err)
+	}
+	if err := r.RegisterGauge("jobs", g); !errors.Is(err, hops.ErrAlreadyRegistered) {
+		t.Errorf("expected: %v, got: %v", hops.ErrAlreadyRegistered, err)
+	}
+	if r.Gauge("queue.size") != g || r.Get("queue.size") != nil {
+		t.Errorf("expected the gauge to be registered apart from counters")
+	}
+
+	r.Unregister("queue.size")
+	if r.Gauge("queue.size") != nil || len(r.GaugeNames()) != 0 {
+		t.Errorf("expected no gauge after Unregister")
+	}
+}
@@ -0,0 +1,175 @@
+package hops
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// GCRA is a rate limiter implementing the Generic Cell Rate Algorithm, a
+// variant of the leaky bucket. It keeps a single timestamp, the theoretical
+// arrival time of the next event, and updates it with a compare-and-swap,
+// so it's constant-time and takes a few bytes of memory. That makes it a
+// good fit for limiting a very large number of keys.
+//
+// It allows the same long-term rate and bursts as a TokenBucket with the
+// same parameters.
+//
+// It's safe to use the limiter concurrently.
+type GCRA struct {
+	// Theoretical arrival time of the next event, as Unix time in
+	// nanoseconds. Use only atomic operations to read and write to this
+	// field.
+	tat int64
+
+	// Latest time instant passed to Tick, as Unix time in nanoseconds.
+	// Use only atomic operations to read and write to this field.
+	tickTime int64
+
+	params atomic.Pointer[gcraParams]
+
+	manual bool
+}
+
+// gcraParams are the parameters of a GCRA limiter. They are replaced as a
+// whole when the limit changes, so they are always consistent.
+type gcraParams struct {
+	limit  int
+	period time.Duration
+	burst  int
+
+	// Time between two events at the sustained rate, in nanoseconds, or 0
+	// if the limit denies all events
+	interval int64
+}
+
+func newGCRAParams(limit int, period time.Duration, burst int) *gcraParams {
+	p := &gcraParams{limit: limit, period: period, burst: burst}
+	if limit > 0 {
+		p.interval = int64(period) / int64(limit)
+	}
+	return p
+}
+
+// NewGCRA creates a limiter that allows limit events per period on average,
+// and bursts of up to burst events.
+//
+// For example, NewGCRA(100, time.Minute, 10) allows 100 events per minute,
+// and at most 10 at once. A limit of 0 or less denies all events.
+func NewGCRA(limit int, period time.Duration, burst int) *GCRA {
+	g := &GCRA{}
+	g.params.Store(newGCRAParams(limit, period, burst))
+	return g
+}
+
+// NewManualGCRA creates a GCRA limiter that doesn't follow the wall clock.
+// Time only moves forward when the application calls Tick.
+func NewManualGCRA(limit int, period time.Duration, burst int, now time.Time) *GCRA {
+	g := NewGCRA(limit, period, burst)
+	g.manual = true
+	g.tickTime = now.UnixNano()
+	return g
+}
+
+// Allow reports whether an event may happen now, and records it if so
+func (g *GCRA) Allow() bool {
+	now := g.now()
+	p := g.params.Load()
+	if p.interval == 0 {
+		return false
+	}
+	for {
+		prevTAT := atomic.LoadInt64(&g.tat)
+		tat := prevTAT
+		if tat < now {
+			tat = now
+		}
+
+		// The event is allowed if it doesn't arrive earlier than the burst
+		// tolerance permits
+		newTAT := tat + p.interval
+		if now < newTAT-int64(p.burst)*p.interval {
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&g.tat, prevTAT, newTAT) {
+			return true
+		}
+	}
+}
+
+// Status returns the state of the limit at the current moment in time.
+// Limit is the burst size, and Reset is the time until a full burst is
+// allowed again.
+func (g *GCRA) Status() LimitStatus {
+	now := g.now()
+	p := g.params.Load()
+	if p.interval == 0 {
+		// Nothing is ever allowed, so there is no capacity to free up
+		return LimitStatus{Limit: p.burst}
+	}
+	tat := atomic.LoadInt64(&g.tat)
+	if tat < now {
+		tat = now
+	}
+
+	status := LimitStatus{
+		Limit:     p.burst,
+		Remaining: int((now - (tat - int64(p.burst)*p.interval)) / p.interval),
+		Reset:     time.Duration(tat - now),
+	}
+	if status.Remaining > p.burst {
+		status.Remaining = p.burst
+	}
+	if status.Remaining < 0 {
+		status.Remaining = 0
+	}
+	return status
+}
+
+// SetLimit changes the number of events allowed per period. The change
+// takes effect immediately, and keeps the events already recorded. A limit
+// of 0 or less denies all events.
+func (g *GCRA) SetLimit(limit int) {
+	for {
+		p := g.params.Load()
+		if g.params.CompareAndSwap(p, newGCRAParams(limit, p.period, p.burst)) {
+			return
+		}
+	}
+}
+
+// SetBurst changes the maximum number of events allowed at once. The change
+// takes effect immediately, and keeps the events already recorded.
+func (g *GCRA) SetBurst(burst int) {
+	for {
+		p := g.params.Load()
+		if g.params.CompareAndSwap(p, newGCRAParams(p.limit, p.period, burst)) {
+			return
+		}
+	}
+}
+
+// Tick advances a manual limiter to the given time instant. Time instants
+// older than the latest one passed to Tick are ignored.
+//
+// Tick has no effect on limiters that aren't created by NewManualGCRA.
+func (g *GCRA) Tick(now time.Time) {
+	if !g.manual {
+		return
+	}
+
+	nanos := now.UnixNano()
+	for {
+		last := atomic.LoadInt64(&g.tickTime)
+		if nanos <= last || atomic.CompareAndSwapInt64(&g.tickTime, last, nanos) {
+			return
+		}
+	}
+}
+
+// now returns the current time instant as Unix time in nanoseconds
+func (g *GCRA) now() int64 {
+	if g.manual {
+		return atomic.LoadInt64(&g.tickTime)
+	}
+	return time.Now().UnixNano()
+}
@@ -0,0 +1,100 @@
+package hops_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestGCRA(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	g := hops.NewManualGCRA(60, time.Minute, 3, start)
+
+	for i := 0; i < 3; i++ {
+		if !g.Allow() {
+			t.Fatalf("expected event %d of the burst to be allowed", i)
+		}
+	}
+	if g.Allow() {
+		t.Errorf("expected events over the burst to be denied")
+	}
+
+	status := g.Status()
+	if status.Limit != 3 || status.Remaining != 0 || status.Reset != 3*time.Second {
+		t.Errorf("expected limit: 3, remaining: 0, reset: 3s, got: %+v", status)
+	}
+
+	// One event per second is sustained
+	g.Tick(start.Add(time.Second))
+	if !g.Allow() {
+		t.Errorf("expected an event to be allowed after one interval")
+	}
+	if g.Allow() {
+		t.Errorf("expected only one event to be allowed after one interval")
+	}
+
+	g.Tick(start.Add(time.Hour))
+	if got := g.Status().Remaining; got != 3 {
+		t.Errorf("expected remaining: 3, got: %d", got)
+	}
+}
+
+func TestGCRAConcurrently(t *testing.T) {
+	g := hops.NewGCRA(1, time.Hour, 100)
+
+	var allowed int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if g.Allow() {
+					atomic.AddInt32(&allowed, 1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != 100 {
+		t.Errorf("expected exactly the burst to be allowed: 100, got: %d", allowed)
+	}
+}
+
+func TestGCRADenyAll(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		limit int
+	}{
+		"zero":     {0},
+		"negative": {-1},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := hops.NewManualGCRA(tt.limit, time.Minute, 3, start)
+			g.Tick(start.Add(time.Hour))
+			if g.Allow() {
+				t.Errorf("expected all events to be denied")
+			}
+			if got := g.Status(); got.Remaining != 0 {
+				t.Errorf("expected remaining: 0, got: %+v", got)
+			}
+
+			// Lifting the limit allows events again
+			g.SetLimit(60)
+			if !g.Allow() {
+				t.Errorf("expected events to be allowed after raising the limit")
+			}
+			g.SetLimit(tt.limit)
+			if g.Allow() {
+				t.Errorf("expected all events to be denied after lowering the limit")
+			}
+		})
+	}
+}
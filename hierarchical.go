@@ -0,0 +1,135 @@
+package hops
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// LimitLevel identifies the level of a HierarchicalLimiter that denied an
+// event
+type LimitLevel int
+
+// Levels of a HierarchicalLimiter
+const (
+	// LevelNone means the event was allowed
+	LevelNone LimitLevel = iota
+
+	// LevelKey means the event was denied by the limit of its key
+	LevelKey
+
+	// LevelGlobal means the event was denied by the global limit
+	LevelGlobal
+)
+
+func (l LimitLevel) String() string {
+	switch l {
+	case LevelNone:
+		return "none"
+	case LevelKey:
+		return "key"
+	case LevelGlobal:
+		return "global"
+	default:
+		return "unknown"
+	}
+}
+
+// HierarchicalLimiter nests a limit for each key under a global limit, e.g.
+// "100 requests per second per user, and 5000 per second overall". An event
+// is allowed only if both limits allow it, and then it counts against both.
+//
+// The limiters it's made of must only be used through it. An event allowed
+// by its key but denied globally is taken back from the limiter of its key,
+// which all the limiters of this package support; other limiters keep it.
+//
+// It's safe to use the limiter concurrently.
+type HierarchicalLimiter struct {
+	// Makes checking and updating both levels atomic
+	mu sync.Mutex
+
+	global Limiter
+	keys   *KeyedLimiter
+}
+
+// NewHierarchicalLimiter creates a limiter that checks the limit of each key
+// with keys, and the overall limit with global
+func NewHierarchicalLimiter(global Limiter, keys *KeyedLimiter) *HierarchicalLimiter {
+	return &HierarchicalLimiter{global: global, keys: keys}
+}
+
+// Allow reports whether an event for the given key may happen now, and
+// records it at both levels if so. If the event is denied, it also returns
+// the level that denied it. The limit of the key is checked first.
+//
+// In dry-run mode (see KeyedLimiter.SetDryRun), all events are allowed, but
+// the level that would have denied them is still returned.
+func (h *HierarchicalLimiter) Allow(key string) (bool, LimitLevel) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	l := h.keys.Get(key)
+	level := LevelNone
+	switch {
+	case !l.Allow():
+		level = LevelKey
+	case !h.global.Allow():
+		level = LevelGlobal
+		if u, ok := l.(undoer); ok {
+			u.undo()
+		}
+	}
+
+	return h.keys.decide(key, level == LevelNone), level
+}
+
+// undoer is implemented by the limiters that can take back the latest
+// event they allowed
+type undoer interface {
+	undo()
+}
+
+func (l *WindowLimiter) undo() {
+	l.mu.Lock()
+	l.c.unobserve()
+	l.mu.Unlock()
+}
+
+func (l *SlidingLog) undo() {
+	l.mu.Lock()
+	if len(l.log) > 0 {
+		l.log = l.log[:len(l.log)-1]
+	}
+	l.mu.Unlock()
+}
+
+func (b *TokenBucket) undo() {
+	b.mu.Lock()
+	b.tokens = min(b.tokens+1, float64(b.burst))
+	b.mu.Unlock()
+}
+
+func (g *GCRA) undo() {
+	atomic.AddInt64(&g.tat, -g.params.Load().interval)
+}
+
+// unobserve removes an event from the current time unit, if there is one
+func (c *Counter) unobserve() {
+	if c.isPacked {
+		unit := c.packedUnit(c.now())
+		for {
+			old := c.packed.Load()
+			oldUnit, count := unpackWord(old)
+			if oldUnit != unit || count == 0 || c.packed.CompareAndSwap(old, packWord(unit, count-1)) {
+				return
+			}
+		}
+	}
+
+	c.refreshWindow()
+	for {
+		count := atomic.LoadUint32(&c.crtCount)
+		if count == 0 || atomic.CompareAndSwapUint32(&c.crtCount, count, count-1) {
+			return
+		}
+	}
+}
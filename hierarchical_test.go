@@ -0,0 +1,79 @@
+package hops_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestHierarchicalLimiter(t *testing.T) {
+	global := hops.NewWindowLimiter(hops.NewCounter(5, time.Minute), 3)
+	keys := hops.NewKeyedLimiter(func() hops.Limiter {
+		return hops.NewWindowLimiter(hops.NewCounter(5, time.Minute), 2)
+	})
+	l := hops.NewHierarchicalLimiter(global, keys)
+
+	tests := []struct {
+		key         string
+		wantAllowed bool
+		wantLevel   hops.LimitLevel
+	}{
+		{"alice", true, hops.LevelNone},
+		{"alice", true, hops.LevelNone},
+		{"alice", false, hops.LevelKey},
+		{"bob", true, hops.LevelNone},
+		// Bob is under his own limit, but the global one is used up
+		{"bob", false, hops.LevelGlobal},
+		{"carol", false, hops.LevelGlobal},
+	}
+
+	for i, tt := range tests {
+		allowed, level := l.Allow(tt.key)
+		if allowed != tt.wantAllowed || level != tt.wantLevel {
+			t.Errorf("event %d (%s): expected: %v/%v, got: %v/%v",
+				i, tt.key, tt.wantAllowed, tt.wantLevel, allowed, level)
+		}
+	}
+
+	// Denied events don't count against any level
+	if got := keys.Get("bob").Status().Remaining; got != 1 {
+		t.Errorf("expected bob to have 1 event left, got: %d", got)
+	}
+	if got := keys.Metrics().Denied.Value(); got != 3 {
+		t.Errorf("expected denied: 3, got: %d", got)
+	}
+}
+
+func TestHierarchicalLimiterTakesBackKeyEvents(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		newLimiter func() hops.Limiter
+	}{
+		"window_limiter": {func() hops.Limiter { return hops.NewWindowLimiter(hops.NewCounter(5, time.Minute), 2) }},
+		"sliding_log":    {func() hops.Limiter { return hops.NewManualSlidingLog(2, time.Minute, start) }},
+		"token_bucket":   {func() hops.Limiter { return hops.NewManualTokenBucket(1, 2, start) }},
+		"gcra":           {func() hops.Limiter { return hops.NewManualGCRA(2, time.Minute, 2, start) }},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			global := hops.NewManualTokenBucket(1, 1, start)
+			keys := hops.NewKeyedLimiter(tt.newLimiter)
+			l := hops.NewHierarchicalLimiter(global, keys)
+
+			l.Allow("alice")
+			for i := 0; i < 3; i++ {
+				if allowed, level := l.Allow("alice"); allowed || level != hops.LevelGlobal {
+					t.Fatalf("expected the global limit to deny the event, got: %v/%v", allowed, level)
+				}
+			}
+
+			// The denied events didn't use up alice's limit
+			if got := keys.Get("alice").Status().Remaining; got != 1 {
+				t.Errorf("expected alice to have 1 event left, got: %d", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+// Package hopstest provides a fake clock and assertions for testing code
+// built on hops counters, so tests read declaratively instead of sleeping
+// and polling by hand.
+//
+// Counters, limiters and the other types of hops that are created in
+// manual mode follow a Clock:
+//
+//	clock := hopstest.NewClock(time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC))
+//	c := hops.NewManualCounter(5, time.Second, clock.Now())
+//	clock.Track(c)
+//
+//	c.Observe()
+//	clock.Add(5 * time.Second)
+//	hopstest.SnapshotEquals(t, c, []uint32{0, 0, 0, 0, 0})
+package hopstest
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+// Ticker is implemented by the manual types of hops, which only move
+// forward in time when Tick is called
+type Ticker interface {
+	Tick(now time.Time)
+}
+
+// Clock is a fake clock that ticks the tracked manual types of hops every
+// time it's advanced.
+//
+// It's safe to use the clock concurrently.
+type Clock struct {
+	// Guards now and tickers
+	mu sync.Mutex
+
+	now     time.Time
+	tickers []Ticker
+}
+
+// NewClock creates a clock that starts at the given time instant
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// Now returns the current time instant of the clock
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Track makes the clock tick the given manual types every time it's
+// advanced. They're ticked to the current time instant of the clock right
+// away.
+func (c *Clock) Track(tickers ...Ticker) {
+	c.mu.Lock()
+	c.tickers = append(c.tickers, tickers...)
+	now := c.now
+	c.mu.Unlock()
+
+	for _, t := range tickers {
+		t.Tick(now)
+	}
+}
+
+// Add advances the clock by d and ticks the tracked manual types
+func (c *Clock) Add(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+// Set moves the clock to the given time instant and ticks the tracked
+// manual types. Moving it back in time has no effect on them.
+func (c *Clock) Set(now time.Time) {
+	c.mu.Lock()
+	c.now = now
+	tickers := slices.Clone(c.tickers)
+	c.mu.Unlock()
+
+	for _, t := range tickers {
+		t.Tick(now)
+	}
+}
+
+// Eventually checks that the number of events within the window of c
+// reaches want within the given duration of real time, e.g. when the code
+// under test records events from another goroutine. It fails the test
+// otherwise.
+func Eventually(t testing.TB, c *hops.Counter, want int, within time.Duration) {
+	t.Helper()
+
+	deadline := time.Now().Add(within)
+	for {
+		got := c.Value()
+		if got == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Errorf("expected %d events within %v, got: %d", want, within, got)
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// SnapshotEquals checks that the counts of each time unit of the window of
+// c, from the oldest to the current one, are want. It fails the test
+// otherwise.
+func SnapshotEquals(t testing.TB, c *hops.Counter, want []uint32) {
+	t.Helper()
+
+	if got := c.Snapshot().Counts; !slices.Equal(got, want) {
+		t.Errorf("expected counts: %v, got: %v", want, got)
+	}
+}
+
+// ValueEquals checks that the number of events within the window of c is
+// want. It fails the test otherwise.
+func ValueEquals(t testing.TB, c *hops.Counter, want int) {
+	t.Helper()
+
+	if got := c.Value(); got != want {
+		t.Errorf("expected: %d events, got: %d", want, got)
+	}
+}
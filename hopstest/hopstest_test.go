@@ -0,0 +1,77 @@
+package hopstest_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+	"github.com/ocpodariu/hops/hopstest"
+)
+
+// recorder captures the failures of an assertion
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestClock(t *testing.T) {
+	clock := hopstest.NewClock(time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC))
+	c := hops.NewManualCounter(3, time.Second, clock.Now())
+	l := hops.NewManualSlidingLog(1, time.Second, clock.Now())
+	clock.Track(c, l)
+
+	c.Observe()
+	l.Allow()
+	clock.Add(time.Second)
+	c.Observe()
+	c.Observe()
+
+	hopstest.SnapshotEquals(t, c, []uint32{0, 1, 2})
+	if !l.Allow() {
+		t.Errorf("expected the limiter to follow the clock")
+	}
+
+	clock.Add(time.Minute)
+	hopstest.ValueEquals(t, c, 0)
+}
+
+func TestAssertions(t *testing.T) {
+	c := hops.NewManualCounter(5, time.Minute, time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC))
+	go func() {
+		for i := 0; i < 3; i++ {
+			c.Observe()
+		}
+	}()
+
+	tests := map[string]struct {
+		assert       func(t testing.TB)
+		wantFailures int
+	}{
+		"eventually":         {func(t testing.TB) { hopstest.Eventually(t, c, 3, time.Second) }, 0},
+		"eventually_timeout": {func(t testing.TB) { hopstest.Eventually(t, c, 4, 10*time.Millisecond) }, 1},
+		"value_equals":       {func(t testing.TB) { hopstest.ValueEquals(t, c, 3) }, 0},
+		"value_differs":      {func(t testing.TB) { hopstest.ValueEquals(t, c, 2) }, 1},
+		"snapshot_equals":    {func(t testing.TB) { hopstest.SnapshotEquals(t, c, []uint32{0, 0, 0, 0, 3}) }, 0},
+		"snapshot_differs":   {func(t testing.TB) { hopstest.SnapshotEquals(t, c, []uint32{3}) }, 1},
+	}
+
+	// Wait for the events before checking them synchronously
+	hopstest.Eventually(t, c, 3, time.Second)
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := &recorder{TB: t}
+			tt.assert(r)
+			if len(r.failures) != tt.wantFailures {
+				t.Errorf("expected: %d failures, got: %v", tt.wantFailures, r.failures)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package hopstest
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+// Scenario runs scripts of timed actions and assertions against named
+// counters and limiters, under the virtual time of a Clock. It makes
+// temporal edge cases, such as events on the boundary of a time unit,
+// reproducible:
+//
+//	s := hopstest.NewScenario(time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC))
+//	s.Counter("requests", hops.NewManualCounter(3, time.Second, s.Clock().Now()))
+//	s.Limiter("api", hops.NewManualSlidingLog(2, time.Second, s.Clock().Now()))
+//	s.Run(t, `
+//		observe requests 2
+//		allow api 2
+//		deny api
+//		advance 1s
+//		value requests 2
+//		allow api
+//	`)
+//
+// A script has one command per line. Empty lines and lines starting with
+// # are ignored. The commands are:
+//
+//	advance <duration>          advances the clock, e.g. "advance 1m30s"
+//	observe <counter> [n]       observes n events, 1 by default
+//	value <counter> <want>      checks the number of events within the window
+//	counts <counter> <want...>  checks the counts of each time unit, oldest first
+//	allow <limiter> [n]         checks that the next n events are allowed
+//	deny <limiter> [n]          checks that the next n events are denied
+type Scenario struct {
+	clock    *Clock
+	counters map[string]*hops.Counter
+	limiters map[string]hops.Limiter
+}
+
+// NewScenario creates a scenario whose clock starts at the given time
+// instant
+func NewScenario(start time.Time) *Scenario {
+	return &Scenario{
+		clock:    NewClock(start),
+		counters: make(map[string]*hops.Counter),
+		limiters: make(map[string]hops.Limiter),
+	}
+}
+
+// Clock returns the clock of the scenario
+func (s *Scenario) Clock() *Clock {
+	return s.clock
+}
+
+// Counter makes c available to scripts under the given name, and makes the
+// clock tick it
+func (s *Scenario) Counter(name string, c *hops.Counter) {
+	s.counters[name] = c
+	s.clock.Track(c)
+}
+
+// Limiter makes l available to scripts under the given name, and makes the
+// clock tick it. A WindowLimiter is ticked through its counter, so the
+// counter must be a manual one too.
+//
+// It panics if l doesn't implement Ticker, since it wouldn't follow the
+// clock of the scenario.
+func (s *Scenario) Limiter(name string, l hops.Limiter) {
+	ticker, ok := l.(Ticker)
+	if !ok {
+		panic(fmt.Sprintf("hopstest: limiter %q of type %T can't be ticked by the scenario", name, l))
+	}
+	s.limiters[name] = l
+	s.clock.Track(ticker)
+}
+
+// Run executes the script, failing the test for every assertion that
+// doesn't hold. It stops at the first malformed command.
+func (s *Scenario) Run(t testing.TB, script string) {
+	t.Helper()
+
+	for i, line := range strings.Split(script, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if err := s.exec(t, i+1, fields); err != nil {
+			t.Errorf("line %d: %v: %q", i+1, err, strings.TrimSpace(line))
+			return
+		}
+	}
+}
+
+// exec executes a single command, and fails if it's malformed
+func (s *Scenario) exec(t testing.TB, line int, fields []string) error {
+	t.Helper()
+
+	cmd, args := fields[0], fields[1:]
+	switch cmd {
+	case "advance":
+		if len(args) != 1 {
+			return errors.New("expected a duration")
+		}
+		d, err := time.ParseDuration(args[0])
+		if err != nil {
+			return err
+		}
+		s.clock.Add(d)
+
+	case "observe", "value", "counts":
+		if len(args) == 0 {
+			return errors.New("expected a counter")
+		}
+		c, ok := s.counters[args[0]]
+		if !ok {
+			return errors.New("unknown counter")
+		}
+		nums, err := parseInts(args[1:])
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case cmd == "observe" && len(nums) <= 1:
+			for range count(nums) {
+				c.Observe()
+			}
+		case cmd == "value" && len(nums) == 1:
+			if got := c.Value(); got != nums[0] {
+				t.Errorf("line %d: expected %s: %d events, got: %d", line, args[0], nums[0], got)
+			}
+		case cmd == "counts":
+			want := make([]uint32, len(nums))
+			for i, n := range nums {
+				want[i] = uint32(n)
+			}
+			if got := c.Snapshot().Counts; !slices.Equal(got, want) {
+				t.Errorf("line %d: expected %s counts: %v, got: %v", line, args[0], want, got)
+			}
+		default:
+			return errors.New("wrong number of arguments")
+		}
+
+	case "allow", "deny":
+		if len(args) == 0 {
+			return errors.New("expected a limiter")
+		}
+		l, ok := s.limiters[args[0]]
+		if !ok {
+			return errors.New("unknown limiter")
+		}
+		nums, err := parseInts(args[1:])
+		if err != nil {
+			return err
+		}
+		if len(nums) > 1 {
+			return errors.New("wrong number of arguments")
+		}
+
+		want, decision := cmd == "allow", "denied"
+		if want {
+			decision = "allowed"
+		}
+		for i := range count(nums) {
+			if l.Allow() != want {
+				t.Errorf("line %d: expected event %d of %s to be %s", line, i+1, args[0], decision)
+			}
+		}
+
+	default:
+		return errors.New("unknown command")
+	}
+	return nil
+}
+
+// parseInts parses non-negative integer arguments
+func parseInts(args []string) ([]int, error) {
+	nums := make([]int, len(args))
+	for i, arg := range args {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 0 {
+			return nil, errors.New("expected a non-negative integer")
+		}
+		nums[i] = n
+	}
+	return nums, nil
+}
+
+// count returns the optional repetition count of a command
+func count(nums []int) int {
+	if len(nums) == 0 {
+		return 1
+	}
+	return nums[0]
+}
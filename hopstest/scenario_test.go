@@ -0,0 +1,87 @@
+package hopstest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+	"github.com/ocpodariu/hops/hopstest"
+)
+
+func TestScenario(t *testing.T) {
+	tests := map[string]struct {
+		script       string
+		wantFailures int
+	}{
+		"passing": {`
+			# Events on both sides of a time unit boundary
+			observe requests 2
+			allow api 2
+			deny api
+			advance 999ms
+			observe requests
+			advance 1ms
+			counts requests 0 3 0
+			value requests 3
+			allow api
+		`, 0},
+		"failing_assertions": {`
+			observe requests
+			value requests 2
+			deny api
+			allow api 2
+		`, 3},
+		"unknown_counter":  {"observe responses", 1},
+		"unknown_command":  {"sleep 1s", 1},
+		"bad_duration":     {"advance soon", 1},
+		"bad_count":        {"observe requests -1", 1},
+		"missing_argument": {"value requests", 1},
+		"stops_when_malformed": {`
+			advance
+			value requests 5
+		`, 1},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := hopstest.NewScenario(time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC))
+			s.Counter("requests", hops.NewManualCounter(3, time.Second, s.Clock().Now()))
+			s.Limiter("api", hops.NewManualSlidingLog(2, time.Second, s.Clock().Now()))
+
+			r := &recorder{TB: t}
+			s.Run(r, tt.script)
+			if len(r.failures) != tt.wantFailures {
+				t.Errorf("expected: %d failures, got: %v", tt.wantFailures, r.failures)
+			}
+		})
+	}
+}
+
+func TestScenarioWindowLimiter(t *testing.T) {
+	s := hopstest.NewScenario(time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC))
+	s.Limiter("api", hops.NewWindowLimiter(hops.NewManualCounter(2, time.Second, s.Clock().Now()), 1))
+	s.Run(t, `
+		allow api
+		deny api
+		advance 1s
+		deny api
+		advance 1s
+		allow api
+	`)
+}
+
+func TestScenarioLimiterWithoutTick(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic for a limiter that can't be ticked")
+		}
+	}()
+
+	s := hopstest.NewScenario(time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC))
+	s.Limiter("api", untickable{hops.NewManualSlidingLog(2, time.Second, s.Clock().Now())})
+}
+
+// untickable hides the Tick method of the limiter it wraps
+type untickable struct {
+	hops.Limiter
+}
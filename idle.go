@@ -0,0 +1,97 @@
+package hops
+
+import (
+	"sync"
+	"time"
+)
+
+// idleWatcher calls fn when a counter sees no events for a given duration
+type idleWatcher struct {
+	c  *Counter
+	d  time.Duration
+	fn func()
+
+	// Guards timer, firedFor and stopped
+	mu sync.Mutex
+
+	// Schedules the next check. Nil for manual counters, which are checked
+	// on every Tick instead.
+	timer *time.Timer
+
+	// Unix time, in nanoseconds, of the most recent event when fn was last
+	// called. Ensures fn is called only once per idle period.
+	firedFor int64
+
+	stopped bool
+}
+
+// OnIdle calls fn when no events have been observed for the duration d.
+// It's called once per idle period: after it fires, fn is called again
+// only if new events are observed and then stop for d once more.
+//
+// For regular counters, the check runs in the background and fn is called
+// from its own goroutine. For manual counters, the check runs on every Tick,
+// and fn is called from Tick.
+//
+// The returned function stops watching the counter.
+func (c *Counter) OnIdle(d time.Duration, fn func()) (stop func()) {
+	w := &idleWatcher{c: c, d: d, fn: fn, firedFor: -1}
+
+	if c.manual {
+		c.mu.Lock()
+		c.idleWatchers = append(c.idleWatchers, w)
+		c.mu.Unlock()
+	} else {
+		w.mu.Lock()
+		w.timer = time.AfterFunc(d, func() {
+			next := w.check()
+
+			w.mu.Lock()
+			if !w.stopped {
+				w.timer.Reset(next)
+			}
+			w.mu.Unlock()
+		})
+		w.mu.Unlock()
+	}
+
+	return func() {
+		w.mu.Lock()
+		w.stopped = true
+		if w.timer != nil {
+			w.timer.Stop()
+		}
+		w.mu.Unlock()
+
+		if c.manual {
+			c.mu.Lock()
+			for i, other := range c.idleWatchers {
+				if other == w {
+					c.idleWatchers = append(c.idleWatchers[:i], c.idleWatchers[i+1:]...)
+					break
+				}
+			}
+			c.mu.Unlock()
+		}
+	}
+}
+
+// check calls fn if the counter has been idle for long enough, and returns
+// how long to wait until the next check
+func (w *idleWatcher) check() time.Duration {
+	last := w.c.lastObserved.Load()
+	idle := w.c.TimeSinceLast()
+	if idle < w.d {
+		return w.d - idle
+	}
+
+	w.mu.Lock()
+	fire := !w.stopped && w.firedFor != last
+	w.firedFor = last
+	w.mu.Unlock()
+
+	if fire {
+		w.fn()
+	}
+	return w.d
+}
@@ -0,0 +1,59 @@
+package hops_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestOnIdle(t *testing.T) {
+	c := hops.NewCounter(5, time.Second)
+	c.Observe()
+
+	var calls int32
+	stop := c.OnIdle(30*time.Millisecond, func() {
+		atomic.AddInt32(&calls, 1)
+	})
+	defer stop()
+
+	time.Sleep(100 * time.Millisecond)
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("expected 1 call after going idle, got: %d", got)
+	}
+
+	// Fires again only after events resume and stop once more
+	c.Observe()
+	time.Sleep(100 * time.Millisecond)
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("expected 2 calls, got: %d", got)
+	}
+}
+
+func TestOnIdleManual(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+	c := hops.NewManualCounter(5, time.Second, start)
+	c.Observe()
+
+	calls := 0
+	stop := c.OnIdle(10*time.Second, func() { calls++ })
+
+	c.Tick(start.Add(9 * time.Second))
+	if calls != 0 {
+		t.Errorf("expected no calls before going idle, got: %d", calls)
+	}
+
+	c.Tick(start.Add(10 * time.Second))
+	c.Tick(start.Add(15 * time.Second))
+	if calls != 1 {
+		t.Errorf("expected 1 call, got: %d", calls)
+	}
+
+	stop()
+	c.Observe()
+	c.Tick(start.Add(time.Minute))
+	if calls != 1 {
+		t.Errorf("expected no calls after stop, got: %d", calls)
+	}
+}
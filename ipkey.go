@@ -0,0 +1,96 @@
+package hops
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// IPKeyOptions configures how KeyByIP identifies clients
+type IPKeyOptions struct {
+	// Proxies that are trusted to report the client address in the
+	// X-Forwarded-For header, e.g. the load balancers in front of the
+	// service. The header is ignored for requests coming from anywhere else,
+	// since clients can set it to anything.
+	TrustedProxies []netip.Prefix
+
+	// If set, IPv4 clients are grouped into networks of this prefix length,
+	// e.g. 24 limits each /24 network as a whole
+	IPv4Prefix int
+
+	// If set, IPv6 clients are grouped into networks of this prefix length,
+	// e.g. 64 limits each /64 network as a whole
+	IPv6Prefix int
+}
+
+// KeyByIP returns a KeyFunc that identifies clients by their IP address.
+//
+// The client address is the address of the connection, unless it comes from
+// a trusted proxy. In that case, it's the rightmost address in the
+// X-Forwarded-For header that isn't a trusted proxy itself.
+//
+// Keys are addresses (e.g. "203.0.113.7"), or networks (e.g.
+// "203.0.113.0/24") when the options ask for grouping.
+func KeyByIP(opts IPKeyOptions) KeyFunc {
+	trusted := func(addr netip.Addr) bool {
+		for _, p := range opts.TrustedProxies {
+			if p.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(r *http.Request) string {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		addr, err := netip.ParseAddr(host)
+		if err != nil {
+			return host
+		}
+		addr = addr.Unmap()
+
+		if trusted(addr) {
+			addr = forwardedFor(r, addr, trusted)
+		}
+
+		bits := opts.IPv6Prefix
+		if addr.Is4() {
+			bits = opts.IPv4Prefix
+		}
+		if bits > 0 {
+			if prefix, err := addr.Prefix(bits); err == nil {
+				return prefix.String()
+			}
+		}
+		return addr.String()
+	}
+}
+
+// forwardedFor returns the client address from the X-Forwarded-For headers
+// of a request that came through trusted proxies. It walks the addresses
+// from right to left, since each proxy appends the address it received the
+// request from, and stops at the first one that isn't trusted.
+func forwardedFor(r *http.Request, remote netip.Addr, trusted func(netip.Addr) bool) netip.Addr {
+	var entries []string
+	for _, h := range r.Header.Values("X-Forwarded-For") {
+		entries = append(entries, strings.Split(h, ",")...)
+	}
+
+	client := remote
+	for i := len(entries) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(entries[i]))
+		if err != nil {
+			// Anything left of a malformed entry can't be trusted
+			break
+		}
+		client = addr.Unmap()
+		if !trusted(client) {
+			break
+		}
+	}
+	return client
+}
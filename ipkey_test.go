@@ -0,0 +1,81 @@
+package hops_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestKeyByIP(t *testing.T) {
+	proxies := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := map[string]struct {
+		opts          hops.IPKeyOptions
+		remoteAddr    string
+		forwardedFors []string
+		want          string
+	}{
+		"connection_address": {
+			hops.IPKeyOptions{},
+			"203.0.113.7:1234", nil,
+			"203.0.113.7",
+		},
+		"untrusted_forwarded_for_is_ignored": {
+			hops.IPKeyOptions{TrustedProxies: proxies},
+			"203.0.113.7:1234", []string{"198.51.100.1"},
+			"203.0.113.7",
+		},
+		"trusted_proxy": {
+			hops.IPKeyOptions{TrustedProxies: proxies},
+			"10.0.0.1:1234", []string{"198.51.100.1"},
+			"198.51.100.1",
+		},
+		"chain_of_trusted_proxies": {
+			hops.IPKeyOptions{TrustedProxies: proxies},
+			"10.0.0.1:1234", []string{"192.0.2.99, 198.51.100.1", "10.0.0.2"},
+			"198.51.100.1",
+		},
+		"spoofed_leftmost_entry": {
+			hops.IPKeyOptions{TrustedProxies: proxies},
+			"10.0.0.1:1234", []string{"1.2.3.4, 198.51.100.1"},
+			"198.51.100.1",
+		},
+		"malformed_entry": {
+			hops.IPKeyOptions{TrustedProxies: proxies},
+			"10.0.0.1:1234", []string{"198.51.100.1, bogus, 10.0.0.2"},
+			"10.0.0.2",
+		},
+		"ipv4_network": {
+			hops.IPKeyOptions{IPv4Prefix: 24},
+			"203.0.113.7:1234", nil,
+			"203.0.113.0/24",
+		},
+		"ipv6_network": {
+			hops.IPKeyOptions{IPv4Prefix: 24, IPv6Prefix: 64},
+			"[2001:db8:1:2:3:4:5:6]:1234", nil,
+			"2001:db8:1:2::/64",
+		},
+		"ipv4_mapped_ipv6": {
+			hops.IPKeyOptions{},
+			"[::ffff:203.0.113.7]:1234", nil,
+			"203.0.113.7",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, h := range tt.forwardedFors {
+				r.Header.Add("X-Forwarded-For", h)
+			}
+
+			if got := hops.KeyByIP(tt.opts)(r); got != tt.want {
+				t.Errorf("expected: %s, got: %s", tt.want, got)
+			}
+		})
+	}
+}
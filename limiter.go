@@ -0,0 +1,362 @@
+package hops
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Limiter decides whether events may happen, such that their rate stays
+// under a limit
+type Limiter interface {
+	// Allow reports whether an event may happen now, and records it if so
+	Allow() bool
+
+	// Status returns the state of the limit at the current moment in time
+	Status() LimitStatus
+}
+
+// LimitStatus describes the state of a limit at a moment in time
+type LimitStatus struct {
+	// Maximum number of events allowed
+	Limit int
+
+	// Number of events still allowed at this moment
+	Remaining int
+
+	// Time until capacity is freed up, or 0 if the limiter is at full
+	// capacity
+	Reset time.Duration
+}
+
+// WindowLimiter allows at most a given number of events within the window
+// of a counter, i.e. it's a sliding window rate limiter with a precision of
+// one time unit.
+//
+// It's safe to use the limiter concurrently.
+type WindowLimiter struct {
+	// Serializes the check and the update of the counter
+	mu sync.Mutex
+
+	c     *Counter
+	limit int
+
+	// Reservations whose events are still to be recorded, in order
+	pending []*Reservation
+}
+
+// NewWindowLimiter creates a limiter that allows at most limit events within
+// the window of c. The counter must be used only by this limiter.
+//
+// For example, this allows at most 100 events in the last minute:
+//
+//	l := hops.NewWindowLimiter(hops.NewCounter(60, time.Second), 100)
+func NewWindowLimiter(c *Counter, limit int) *WindowLimiter {
+	return &WindowLimiter{c: c, limit: limit}
+}
+
+// Allow reports whether an event may happen now, and records it if so
+func (l *WindowLimiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.flush(l.c.now())
+	if l.c.Value()+l.pendingEvents() >= l.limit {
+		return false
+	}
+	l.c.Observe()
+	return true
+}
+
+// Status returns the state of the limit at the current moment in time
+func (l *WindowLimiter) Status() LimitStatus {
+	l.mu.Lock()
+	l.flush(l.c.now())
+	s := l.c.Snapshot()
+	pending := l.pendingEvents()
+	l.mu.Unlock()
+
+	status := LimitStatus{Limit: l.limit, Remaining: l.limit - s.Total - pending}
+	if status.Remaining < 0 {
+		status.Remaining = 0
+	}
+
+	// Capacity is freed up when the oldest time unit with events falls out
+	// of the window
+	for i, n := range s.Counts {
+		if n > 0 {
+			expiry := s.Start.Add(l.c.WindowSize + time.Duration(i)*s.Unit)
+			status.Reset = expiry.Sub(l.c.now())
+			break
+		}
+	}
+	return status
+}
+
+// SetLimit changes the number of events allowed within the window. The
+// change takes effect immediately, and keeps the events already in the
+// window.
+func (l *WindowLimiter) SetLimit(limit int) {
+	l.mu.Lock()
+	l.limit = limit
+	l.mu.Unlock()
+}
+
+// KeyedLimiter holds a separate limiter for each key, e.g. for each client
+// or tenant.
+//
+// It's safe to use the limiter concurrently.
+type KeyedLimiter struct {
+	mu       sync.RWMutex
+	limiters map[string]Limiter
+
+	// Guarded by mu
+	newLimiter func() Limiter
+
+	// Limit set by SetLimit, applied to the limiters of new keys. Guarded
+	// by mu.
+	limit    int
+	limitSet bool
+
+	// Maximum number of keys, or 0 for no limit, and the limiter shared by
+	// the keys beyond it. Guarded by mu.
+	maxKeys  int
+	overflow Limiter
+
+	metrics atomic.Pointer[LimiterMetrics]
+
+	// Dry-run mode state
+	dryRun atomic.Bool
+	denied dryRunLog
+
+	// Guards queue
+	waitMu sync.Mutex
+	queue  waitQueue
+}
+
+// NewKeyedLimiter creates a keyed limiter that calls newLimiter to create
+// the limiter of each new key. Its decisions are counted in a window of
+// one minute, with a precision of one second; see SetMetrics to change that.
+//
+// For example, this allows each key at most 100 events per minute:
+//
+//	l := hops.NewKeyedLimiter(func() hops.Limiter {
+//		return hops.NewWindowLimiter(hops.NewCounter(60, time.Second), 100)
+//	})
+func NewKeyedLimiter(newLimiter func() Limiter) *KeyedLimiter {
+	k := &KeyedLimiter{
+		limiters:   make(map[string]Limiter),
+		newLimiter: newLimiter,
+		queue: waitQueue{
+			waiters: make(map[string][]*waiter),
+			wake:    make(chan struct{}, 1),
+		},
+	}
+	k.metrics.Store(NewLimiterMetrics(60, time.Second, false))
+	return k
+}
+
+// Allow reports whether an event for the given key may happen now, and
+// records it if so
+func (k *KeyedLimiter) Allow(key string) bool {
+	_, allowed := k.allow(key)
+	return allowed
+}
+
+// Metrics returns the counters of allowed and denied events
+func (k *KeyedLimiter) Metrics() *LimiterMetrics {
+	return k.metrics.Load()
+}
+
+// SetMetrics replaces the counters of allowed and denied events, e.g. with
+// ones that have a different window or that count each key separately
+func (k *KeyedLimiter) SetMetrics(m *LimiterMetrics) {
+	k.metrics.Store(m)
+}
+
+// allow is like Allow, but also returns the limiter of the key
+func (k *KeyedLimiter) allow(key string) (Limiter, bool) {
+	l := k.Get(key)
+	return l, k.decide(key, l.Allow())
+}
+
+// Get returns the limiter of the given key, creating it if needed
+func (k *KeyedLimiter) Get(key string) Limiter {
+	k.mu.RLock()
+	l, ok := k.limiters[key]
+	if !ok && k.isFull() && k.overflow != nil {
+		l, ok = k.overflow, true
+	}
+	k.mu.RUnlock()
+	if ok {
+		return l
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	// Another goroutine might have created it in the meantime
+	if l, ok := k.limiters[key]; ok {
+		return l
+	}
+
+	if k.isFull() {
+		if k.overflow == nil {
+			k.overflow = k.create()
+		}
+		return k.overflow
+	}
+
+	l = k.create()
+	k.limiters[key] = l
+	return l
+}
+
+// SetMaxKeys limits the number of keys with their own limiter, to protect
+// memory from an explosion of keys, e.g. when they're derived from client
+// addresses. Once the limit is reached, new keys share a single limiter,
+// until Prune makes room. A limit of 0 removes the limit.
+func (k *KeyedLimiter) SetMaxKeys(n int) {
+	k.mu.Lock()
+	k.maxKeys = n
+	k.mu.Unlock()
+}
+
+// Prune forgets the keys whose limiter is at full capacity, i.e. it holds
+// no events and no reservations, and returns how many it removed. That
+// doesn't change any decision, since those keys get a fresh limiter, in
+// the same state, on their next event. It's meant to be called
+// periodically, e.g. every minute, to keep memory bounded by the number of
+// active keys.
+func (k *KeyedLimiter) Prune() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	removed := 0
+	for key, l := range k.limiters {
+		if s := l.Status(); s.Remaining >= s.Limit && s.Reset <= 0 {
+			delete(k.limiters, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// isFull reports whether the limiter reached its limit of keys.
+// It must be called with mu held.
+func (k *KeyedLimiter) isFull() bool {
+	return k.maxKeys > 0 && len(k.limiters) >= k.maxKeys
+}
+
+// create returns a new limiter with the limit set by SetLimit, if any.
+// It must be called with mu held.
+func (k *KeyedLimiter) create() Limiter {
+	l := k.newLimiter()
+	if s, ok := l.(limitSetter); ok && k.limitSet {
+		s.SetLimit(k.limit)
+	}
+	return l
+}
+
+// limitSetter is implemented by the limiters whose limit can change at
+// runtime
+type limitSetter interface {
+	SetLimit(limit int)
+}
+
+// SetLimit changes the limit of every key, including the ones created
+// later. All the limiters of this package support it; other limiters are
+// left unchanged. The change takes effect immediately, and keeps the events
+// already recorded, so limits can be tightened or relaxed during an
+// incident. A limit of 0 denies all events.
+func (k *KeyedLimiter) SetLimit(limit int) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.limit, k.limitSet = limit, true
+	for _, l := range k.limiters {
+		if s, ok := l.(limitSetter); ok {
+			s.SetLimit(limit)
+		}
+	}
+	if s, ok := k.overflow.(limitSetter); ok {
+		s.SetLimit(limit)
+	}
+}
+
+// Algorithm identifies a rate limiting algorithm
+type Algorithm string
+
+// Rate limiting algorithms supported by LimiterConfig
+const (
+	// SlidingWindow is the algorithm of WindowLimiter
+	SlidingWindow Algorithm = "sliding_window"
+
+	// TokenBucketAlgorithm is the algorithm of TokenBucket
+	TokenBucketAlgorithm Algorithm = "token_bucket"
+
+	// GCRAAlgorithm is the algorithm of GCRA
+	GCRAAlgorithm Algorithm = "gcra"
+
+	// SlidingLogAlgorithm is the algorithm of SlidingLog
+	SlidingLogAlgorithm Algorithm = "sliding_log"
+)
+
+// LimiterConfig describes a limit independently of the algorithm that
+// enforces it, so the algorithm can be switched by configuration without
+// touching the code that uses the limiter.
+type LimiterConfig struct {
+	// Algorithm used to enforce the limit. Defaults to SlidingWindow.
+	Algorithm Algorithm
+
+	// Maximum number of events allowed within Window
+	Limit int
+
+	// Period over which Limit events are allowed
+	Window time.Duration
+
+	// Hop size of the sliding window. It must divide Window, and defaults
+	// to a tenth of it. Token buckets ignore it.
+	Unit time.Duration
+}
+
+// NewLimiter creates a limiter as described by the configuration.
+//
+// A sliding window allows Limit events within any Window, with a precision
+// of one Unit. A token bucket holds up to Limit tokens and refills at a rate
+// of Limit tokens per Window. GCRA behaves like the token bucket. A sliding
+// log allows Limit events within any Window, exactly.
+//
+// It fails with ErrInvalidConfig if the configuration is incomplete.
+func (cfg LimiterConfig) NewLimiter() (Limiter, error) {
+	if cfg.Limit <= 0 || cfg.Window <= 0 {
+		return nil, fmt.Errorf("%w: limit and window must be positive", ErrInvalidConfig)
+	}
+
+	switch cfg.Algorithm {
+	case SlidingWindow, "":
+		unit := cfg.Unit
+		if unit == 0 {
+			unit = cfg.Window / 10
+		}
+		if unit <= 0 || cfg.Window%unit != 0 {
+			return nil, fmt.Errorf("%w: unit %v doesn't divide window %v", ErrInvalidConfig, unit, cfg.Window)
+		}
+		c := NewCounter(int(cfg.Window/unit), unit)
+		return NewWindowLimiter(c, cfg.Limit), nil
+
+	case TokenBucketAlgorithm:
+		rate := float64(cfg.Limit) / cfg.Window.Seconds()
+		return NewTokenBucket(rate, cfg.Limit), nil
+
+	case GCRAAlgorithm:
+		return NewGCRA(cfg.Limit, cfg.Window, cfg.Limit), nil
+
+	case SlidingLogAlgorithm:
+		return NewSlidingLog(cfg.Limit, cfg.Window), nil
+
+	default:
+		return nil, fmt.Errorf("%w: unknown algorithm %q", ErrInvalidConfig, cfg.Algorithm)
+	}
+}
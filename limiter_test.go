@@ -0,0 +1,118 @@
+package hops_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestWindowLimiter(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	c := hops.NewManualCounter(3, time.Second, start)
+	l := hops.NewWindowLimiter(c, 2)
+
+	if !l.Allow() || !l.Allow() {
+		t.Fatalf("expected the first two events to be allowed")
+	}
+	if l.Allow() {
+		t.Errorf("expected the third event to be denied")
+	}
+
+	status := l.Status()
+	if status.Limit != 2 || status.Remaining != 0 {
+		t.Errorf("expected limit: 2, remaining: 0, got: %+v", status)
+	}
+	if status.Reset != 3*time.Second {
+		t.Errorf("expected reset: 3s, got: %v", status.Reset)
+	}
+
+	// The first events fall out of the window
+	c.Tick(start.Add(3 * time.Second))
+	if !l.Allow() {
+		t.Errorf("expected an event to be allowed after the window moved")
+	}
+	if got := l.Status().Remaining; got != 1 {
+		t.Errorf("expected remaining: 1, got: %d", got)
+	}
+}
+
+func TestKeyedLimiter(t *testing.T) {
+	l := hops.NewKeyedLimiter(func() hops.Limiter {
+		return hops.NewWindowLimiter(hops.NewCounter(5, time.Minute), 1)
+	})
+
+	if !l.Allow("alice") {
+		t.Errorf("expected alice to be allowed")
+	}
+	if l.Allow("alice") {
+		t.Errorf("expected alice to be denied")
+	}
+	if !l.Allow("bob") {
+		t.Errorf("expected keys to have separate limits")
+	}
+	if l.Get("alice") != l.Get("alice") {
+		t.Errorf("expected the same limiter for the same key")
+	}
+}
+
+func TestKeyedLimiterPrune(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	var counters []*hops.Counter
+	l := hops.NewKeyedLimiter(func() hops.Limiter {
+		c := hops.NewManualCounter(3, time.Second, start)
+		counters = append(counters, c)
+		return hops.NewWindowLimiter(c, 2)
+	})
+
+	l.Allow("alice")
+	l.Allow("alice")
+	l.Allow("bob")
+	l.Get("carol")
+
+	// Only carol's limiter is at full capacity
+	if got := l.Prune(); got != 1 {
+		t.Errorf("expected 1 key to be pruned, got: %d", got)
+	}
+	if l.Allow("alice") {
+		t.Errorf("expected alice's events to be kept")
+	}
+
+	for _, c := range counters {
+		c.Tick(start.Add(3 * time.Second))
+	}
+	if got := l.Prune(); got != 2 {
+		t.Errorf("expected the remaining 2 keys to be pruned, got: %d", got)
+	}
+}
+
+func TestKeyedLimiterMaxKeys(t *testing.T) {
+	l := hops.NewKeyedLimiter(func() hops.Limiter {
+		return hops.NewWindowLimiter(hops.NewCounter(5, time.Minute), 2)
+	})
+	l.SetMaxKeys(2)
+
+	// Keys beyond the cap share a limiter, so carol, dave and erin compete
+	// for the same 2 events
+	steps := []struct {
+		key  string
+		want bool
+	}{
+		{"alice", true},
+		{"bob", true},
+		{"carol", true},
+		{"dave", true},
+		{"erin", false},
+		{"alice", true},
+		{"alice", false},
+	}
+	for i, step := range steps {
+		if got := l.Allow(step.key); got != step.want {
+			t.Errorf("step %d: expected %s: %v, got: %v", i, step.key, step.want, got)
+		}
+	}
+
+	if l.Get("carol") != l.Get("dave") {
+		t.Errorf("expected the keys beyond the cap to share a limiter")
+	}
+}
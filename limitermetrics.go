@@ -0,0 +1,140 @@
+package hops
+
+import "time"
+
+// LimiterMetrics counts the decisions of a limiter in hopping windows, so
+// its effectiveness can be observed without extra instrumentation
+type LimiterMetrics struct {
+	// Number of allowed and denied events
+	Allowed *Counter
+	Denied  *Counter
+
+	// Number of allowed and denied events for each key, with a single label
+	// called "key". Optional, since keeping a counter for every key can take
+	// a lot of memory.
+	AllowedByKey *CounterVec
+	DeniedByKey  *CounterVec
+}
+
+// NewLimiterMetrics creates metrics with the given window size and time
+// unit, keeping counters for each key as well if perKey is set
+func NewLimiterMetrics(windowSize int, timeUnit time.Duration, perKey bool) *LimiterMetrics {
+	m := &LimiterMetrics{
+		Allowed: NewCounter(windowSize, timeUnit),
+		Denied:  NewCounter(windowSize, timeUnit),
+	}
+	if perKey {
+		m.AllowedByKey = NewCounterVec(windowSize, timeUnit, "key")
+		m.DeniedByKey = NewCounterVec(windowSize, timeUnit, "key")
+	}
+	return m
+}
+
+// Register adds the allowed and denied counters to r, under the given
+// prefix followed by ".allowed" and ".denied"
+func (m *LimiterMetrics) Register(r *Registry, prefix string) error {
+	if err := r.Register(prefix+".allowed", m.Allowed); err != nil {
+		return err
+	}
+	return r.Register(prefix+".denied", m.Denied)
+}
+
+// record counts a decision for the given key
+func (m *LimiterMetrics) record(key string, allowed bool) {
+	if allowed {
+		m.Allowed.Observe()
+		if m.AllowedByKey != nil {
+			m.AllowedByKey.WithLabelValues(key).Observe()
+		}
+		return
+	}
+
+	m.Denied.Observe()
+	if m.DeniedByKey != nil {
+		m.DeniedByKey.WithLabelValues(key).Observe()
+	}
+}
+
+// instrumentedLimiter counts the decisions of the limiter it wraps
+type instrumentedLimiter struct {
+	Limiter
+	metrics *LimiterMetrics
+}
+
+// instrumentedWindowLimiter counts the decisions of a WindowLimiter,
+// including its reservations
+type instrumentedWindowLimiter struct {
+	*instrumentedLimiter
+	wl *WindowLimiter
+}
+
+// Instrument returns a limiter that behaves like l and counts its decisions
+// in m. Keyed limiters count their decisions on their own, see
+// KeyedLimiter.Metrics.
+//
+// The limiter keeps the optional methods of the limiters of this package:
+// SetLimit, Tick and AllowPriority, which fall back to Allow for limiters
+// that don't implement PriorityLimiter, and Reserve and ReserveN for a
+// WindowLimiter. Unwrap returns l, for the other methods.
+func Instrument(l Limiter, m *LimiterMetrics) Limiter {
+	il := &instrumentedLimiter{Limiter: l, metrics: m}
+	if wl, ok := l.(*WindowLimiter); ok {
+		return &instrumentedWindowLimiter{instrumentedLimiter: il, wl: wl}
+	}
+	return il
+}
+
+func (l *instrumentedLimiter) Allow() bool {
+	allowed := l.Limiter.Allow()
+	l.metrics.record("", allowed)
+	return allowed
+}
+
+// AllowPriority reports whether an event of the given priority may happen
+// now, and records it if so
+func (l *instrumentedLimiter) AllowPriority(p Priority) bool {
+	pl, ok := l.Limiter.(PriorityLimiter)
+	if !ok {
+		return l.Allow()
+	}
+	allowed := pl.AllowPriority(p)
+	l.metrics.record("", allowed)
+	return allowed
+}
+
+// SetLimit changes the limit of the wrapped limiter, if it supports it
+func (l *instrumentedLimiter) SetLimit(limit int) {
+	if s, ok := l.Limiter.(limitSetter); ok {
+		s.SetLimit(limit)
+	}
+}
+
+// Tick advances the wrapped limiter to the given time instant, if it's a
+// manual limiter
+func (l *instrumentedLimiter) Tick(now time.Time) {
+	if t, ok := l.Limiter.(interface{ Tick(now time.Time) }); ok {
+		t.Tick(now)
+	}
+}
+
+// Unwrap returns the wrapped limiter
+func (l *instrumentedLimiter) Unwrap() Limiter {
+	return l.Limiter
+}
+
+// Reserve is shorthand for ReserveN(now, 1) at the current moment in time.
+// Reservations that are OK count as allowed events, the others as denied.
+func (l *instrumentedWindowLimiter) Reserve() *Reservation {
+	return l.ReserveN(l.wl.c.now(), 1)
+}
+
+// ReserveN reserves capacity for n events, as WindowLimiter.ReserveN does.
+// Reservations that are OK count as n allowed events, the others as n
+// denied events.
+func (l *instrumentedWindowLimiter) ReserveN(now time.Time, n int) *Reservation {
+	r := l.wl.ReserveN(now, n)
+	for i := 0; i < n; i++ {
+		l.metrics.record("", r.OK())
+	}
+	return r
+}
@@ -0,0 +1,129 @@
+package hops_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestKeyedLimiterMetrics(t *testing.T) {
+	l := hops.NewKeyedLimiter(func() hops.Limiter {
+		return hops.NewWindowLimiter(hops.NewCounter(5, time.Minute), 1)
+	})
+
+	l.Allow("alice")
+	l.Allow("alice")
+	l.Allow("bob")
+
+	m := l.Metrics()
+	if m.Allowed.Value() != 2 || m.Denied.Value() != 1 {
+		t.Errorf("expected allowed: 2, denied: 1, got: %d, %d", m.Allowed.Value(), m.Denied.Value())
+	}
+
+	r := hops.NewRegistry()
+	if err := m.Register(r, "api.limiter"); err != nil {
+		t.Fatal(err)
+	}
+	if r.Get("api.limiter.denied") != m.Denied {
+		t.Errorf("expected the denied counter to be registered")
+	}
+}
+
+func TestLimiterMetricsPerKey(t *testing.T) {
+	l := hops.NewKeyedLimiter(func() hops.Limiter {
+		return hops.NewWindowLimiter(hops.NewCounter(5, time.Minute), 1)
+	})
+	l.SetMetrics(hops.NewLimiterMetrics(5, time.Minute, true))
+
+	l.Allow("alice")
+	l.Allow("alice")
+	l.Allow("alice")
+
+	m := l.Metrics()
+	if got := m.AllowedByKey.WithLabelValues("alice").Value(); got != 1 {
+		t.Errorf("expected allowed for alice: 1, got: %d", got)
+	}
+	if got := m.DeniedByKey.WithLabelValues("alice").Value(); got != 2 {
+		t.Errorf("expected denied for alice: 2, got: %d", got)
+	}
+}
+
+func TestInstrument(t *testing.T) {
+	m := hops.NewLimiterMetrics(5, time.Minute, false)
+	l := hops.Instrument(hops.NewWindowLimiter(hops.NewCounter(5, time.Minute), 2), m)
+
+	for i := 0; i < 5; i++ {
+		l.Allow()
+	}
+
+	if m.Allowed.Value() != 2 || m.Denied.Value() != 3 {
+		t.Errorf("expected allowed: 2, denied: 3, got: %d, %d", m.Allowed.Value(), m.Denied.Value())
+	}
+	if l.Status().Limit != 2 {
+		t.Errorf("expected Status to be passed through")
+	}
+}
+
+func TestInstrumentKeepsOptionalMethods(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		limiter hops.Limiter
+
+		// Status().Limit after SetLimit(2); the one of GCRA is its burst
+		wantLimit   int
+		wantReserve bool
+	}{
+		"window_limiter": {hops.NewWindowLimiter(hops.NewManualCounter(5, time.Second, start), 4), 2, true},
+		"sliding_log":    {hops.NewManualSlidingLog(4, 5*time.Second, start), 2, false},
+		"token_bucket":   {hops.NewManualTokenBucket(1, 4, start), 2, false},
+		"gcra":           {hops.NewManualGCRA(4, 5*time.Second, 4, start), 4, false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := hops.NewLimiterMetrics(5, time.Minute, false)
+			l := hops.Instrument(tt.limiter, m)
+
+			pl, ok := l.(hops.PriorityLimiter)
+			if !ok {
+				t.Fatalf("expected AllowPriority to be kept")
+			}
+			pl.AllowPriority(hops.PriorityCritical)
+
+			s, ok := l.(interface{ SetLimit(int) })
+			if !ok {
+				t.Fatalf("expected SetLimit to be kept")
+			}
+			s.SetLimit(2)
+			if got := l.Status().Limit; got != tt.wantLimit {
+				t.Errorf("expected limit: %d, got: %d", tt.wantLimit, got)
+			}
+
+			if _, ok := l.(interface{ Tick(time.Time) }); !ok {
+				t.Errorf("expected Tick to be kept")
+			}
+
+			r, ok := l.(interface{ Reserve() *hops.Reservation })
+			if ok != tt.wantReserve {
+				t.Fatalf("expected Reserve to be kept: %v, got: %v", tt.wantReserve, ok)
+			}
+			if ok {
+				r.Reserve()
+			}
+
+			if got := l.(interface{ Unwrap() hops.Limiter }).Unwrap(); got != tt.limiter {
+				t.Errorf("expected Unwrap to return the wrapped limiter")
+			}
+
+			want := 1
+			if tt.wantReserve {
+				want = 2
+			}
+			if got := m.Allowed.Value(); got != want {
+				t.Errorf("expected allowed: %d, got: %d", want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package hops
+
+import "time"
+
+// Tick advances the counter of the limiter to the given time instant, if
+// it's a manual counter, so simulations such as hopstest.Scenario can
+// drive the limiter along with the other manual limiters.
+//
+// Tick has no effect on limiters whose counter isn't created by
+// NewManualCounter.
+func (l *WindowLimiter) Tick(now time.Time) {
+	l.c.Tick(now)
+}
@@ -0,0 +1,19 @@
+package hops_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestWindowLimiterTick(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	l := hops.NewWindowLimiter(hops.NewManualCounter(3, time.Second, start), 1)
+
+	l.Allow()
+	l.Tick(start.Add(3 * time.Second))
+	if !l.Allow() {
+		t.Errorf("expected the event to be allowed once the window moved")
+	}
+}
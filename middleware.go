@@ -0,0 +1,43 @@
+package hops
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// KeyFunc returns the key that identifies the client of a request, such as
+// its IP address or API token
+type KeyFunc func(r *http.Request) string
+
+// RateLimit returns an HTTP handler that limits the requests of each client,
+// as identified by key, with l. Requests over the limit are rejected with
+// 429 Too Many Requests. If key is nil, clients are identified by the IP
+// address of the connection, as with KeyByIP(IPKeyOptions{}).
+//
+// Every response carries the RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers from the IETF draft on rate limit headers, so
+// clients can throttle themselves. Rejected requests carry a Retry-After
+// header as well.
+func RateLimit(l *KeyedLimiter, key KeyFunc, next http.Handler) http.Handler {
+	if key == nil {
+		key = KeyByIP(IPKeyOptions{})
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limiter, allowed := l.allow(key(r))
+
+		status := limiter.Status()
+		reset := strconv.Itoa(int(math.Ceil(status.Reset.Seconds())))
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(status.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(status.Remaining))
+		w.Header().Set("RateLimit-Reset", reset)
+
+		if !allowed {
+			w.Header().Set("Retry-After", reset)
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
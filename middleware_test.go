@@ -0,0 +1,52 @@
+package hops_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestRateLimit(t *testing.T) {
+	l := hops.NewKeyedLimiter(func() hops.Limiter {
+		return hops.NewWindowLimiter(hops.NewCounter(5, time.Minute), 2)
+	})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := hops.RateLimit(l, nil, ok)
+
+	tests := []struct {
+		remoteAddr    string
+		wantCode      int
+		wantRemaining string
+	}{
+		{"10.0.0.1:1234", http.StatusOK, "1"},
+		{"10.0.0.1:5678", http.StatusOK, "0"},
+		{"10.0.0.1:1234", http.StatusTooManyRequests, "0"},
+		{"10.0.0.2:1234", http.StatusOK, "1"},
+	}
+
+	for i, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = tt.remoteAddr
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if rec.Code != tt.wantCode {
+			t.Errorf("request %d: expected status: %d, got: %d", i, tt.wantCode, rec.Code)
+		}
+		if got := rec.Header().Get("RateLimit-Limit"); got != "2" {
+			t.Errorf("request %d: expected RateLimit-Limit: 2, got: %q", i, got)
+		}
+		if got := rec.Header().Get("RateLimit-Remaining"); got != tt.wantRemaining {
+			t.Errorf("request %d: expected RateLimit-Remaining: %s, got: %q", i, tt.wantRemaining, got)
+		}
+		if rec.Header().Get("RateLimit-Reset") == "" {
+			t.Errorf("request %d: expected a RateLimit-Reset header", i)
+		}
+		if tt.wantCode == http.StatusTooManyRequests && rec.Header().Get("Retry-After") == "" {
+			t.Errorf("request %d: expected a Retry-After header", i)
+		}
+	}
+}
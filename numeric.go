@@ -0,0 +1,136 @@
+package hops
+
+import (
+	"sync"
+	"time"
+)
+
+// Number is the set of types that a CounterOf can accumulate
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// CounterOf uses a hopping window to keep track of the sum of values added
+// in the last W time units, with a hop size of 1 time unit.
+//
+// The type of the values is chosen at compile time. Small types such as
+// uint16 keep the memory footprint low, uint64 protects against overflow
+// when counting a huge number of events, and float64 allows fractional
+// amounts (e.g. bytes in KiB, durations in seconds).
+//
+// It's safe to use this counter concurrently.
+type CounterOf[N Number] struct {
+	// Guards all the fields below
+	mu sync.Mutex
+
+	// Sum of values added in each time unit of the window.
+	// counts[i] = sum of values added (W-1-i) time units ago
+	counts []N
+
+	windowStart time.Time
+
+	// Set for counters that are advanced explicitly through Tick
+	manual bool
+
+	// Latest time instant passed to Tick
+	tickTime time.Time
+
+	WindowSize time.Duration
+	Unit       time.Duration
+}
+
+// NewCounterOf creates a new counter with the given window size and time unit.
+//
+// For example, NewCounterOf[float64](5, time.Minute) creates a counter that
+// keeps track of the sum of values added in the last 5 minutes.
+func NewCounterOf[N Number](windowSize int, timeUnit time.Duration) *CounterOf[N] {
+	return newCounterOf[N](windowSize, timeUnit, time.Now())
+}
+
+// NewManualCounterOf creates a counter that doesn't follow the wall clock.
+// Its window starts at the given time instant and only moves forward when
+// the application calls Tick.
+func NewManualCounterOf[N Number](windowSize int, timeUnit time.Duration, now time.Time) *CounterOf[N] {
+	c := newCounterOf[N](windowSize, timeUnit, now)
+	c.manual = true
+	c.tickTime = now
+	return c
+}
+
+func newCounterOf[N Number](windowSize int, timeUnit time.Duration, now time.Time) *CounterOf[N] {
+	// Initialize the window such that its end is on the current time unit
+	windowStart := now.Truncate(timeUnit).Add(timeUnit)
+	windowStart = windowStart.Add(-1 * time.Duration(windowSize) * timeUnit)
+
+	return &CounterOf[N]{
+		counts:      make([]N, windowSize),
+		windowStart: windowStart,
+		WindowSize:  time.Duration(windowSize) * timeUnit,
+		Unit:        timeUnit,
+	}
+}
+
+// Observe adds 1 to the window at the current moment in time
+func (c *CounterOf[N]) Observe() {
+	c.Add(1)
+}
+
+// Add adds n to the window at the current moment in time
+func (c *CounterOf[N]) Add(n N) {
+	c.mu.Lock()
+	c.refreshWindow()
+	c.counts[len(c.counts)-1] += n
+	c.mu.Unlock()
+}
+
+// Value returns the sum of values within the window
+func (c *CounterOf[N]) Value() N {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.refreshWindow()
+
+	var sum N
+	for _, n := range c.counts {
+		sum += n
+	}
+	return sum
+}
+
+// Tick advances a manual counter to the given time instant, moving its
+// window forward if needed. Time instants older than the latest one passed
+// to Tick are ignored.
+//
+// Tick has no effect on counters that aren't created by NewManualCounterOf.
+func (c *CounterOf[N]) Tick(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.manual || now.Before(c.tickTime) {
+		return
+	}
+	c.tickTime = now
+	c.refreshWindow()
+}
+
+// refreshWindow ensures the end of the window is on the current time unit
+// and removes the values that fall outside of it. Must be called with mu held.
+func (c *CounterOf[N]) refreshWindow() {
+	now := c.tickTime
+	if !c.manual {
+		now = time.Now()
+	}
+
+	// Round the current time to the next multiple of time unit such that
+	// the window will include the current time unit as well
+	end := now.Truncate(c.Unit).Add(c.Unit)
+	if end.Sub(c.windowStart) <= c.WindowSize {
+		return
+	}
+
+	moveDistance := int((end.Sub(c.windowStart) - c.WindowSize) / c.Unit)
+	leftShiftInPlace(c.counts, moveDistance)
+	c.windowStart = c.windowStart.Add(time.Duration(moveDistance) * c.Unit)
+}
@@ -0,0 +1,47 @@
+package hops_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestCounterOf(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+	c := hops.NewManualCounterOf[float64](3, time.Second, start)
+
+	c.Add(0.5)
+	c.Add(1.25)
+	if got := c.Value(); got != 1.75 {
+		t.Errorf("expected: 1.75, got: %v", got)
+	}
+
+	c.Tick(start.Add(2 * time.Second))
+	c.Add(2)
+	if got := c.Value(); got != 3.75 {
+		t.Errorf("expected: 3.75, got: %v", got)
+	}
+
+	// The first values fall outside of the window
+	c.Tick(start.Add(3 * time.Second))
+	if got := c.Value(); got != 2 {
+		t.Errorf("expected: 2, got: %v", got)
+	}
+
+	c.Tick(start.Add(time.Hour))
+	if got := c.Value(); got != 0 {
+		t.Errorf("expected: 0, got: %v", got)
+	}
+}
+
+func TestCounterOfLargeCounts(t *testing.T) {
+	c := hops.NewCounterOf[uint64](5, time.Hour)
+
+	c.Add(1 << 40)
+	c.Observe()
+
+	if got, want := c.Value(), uint64(1<<40+1); got != want {
+		t.Errorf("expected: %d, got: %d", want, got)
+	}
+}
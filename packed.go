@@ -0,0 +1,60 @@
+package hops
+
+import "time"
+
+// Counters with a window of a single time unit, such as "events in the
+// current second", keep their state in a single word that is updated with
+// a compare-and-swap, instead of a slice guarded by a mutex:
+//
+//	bits 63..32: current time unit, counted from the Unix epoch (truncated)
+//	bits 31..0:  number of events in the current time unit
+//
+// NewCounter selects this representation automatically. Manual counters
+// don't use it, since Tick needs the regular window bookkeeping.
+
+// packWord packs a time unit and a count into a single word
+func packWord(unit uint32, count uint32) uint64 {
+	return uint64(unit)<<32 | uint64(count)
+}
+
+// unpackWord splits a word into its time unit and count
+func unpackWord(w uint64) (unit uint32, count uint32) {
+	return uint32(w >> 32), uint32(w)
+}
+
+// packedUnit returns the time unit of the given time instant, as stored in
+// a packed word
+func (c *Counter) packedUnit(now time.Time) uint32 {
+	return uint32(now.UnixNano() / int64(c.Unit))
+}
+
+// observePacked adds an event to the packed word at the given time instant
+func (c *Counter) observePacked(now time.Time) {
+	c.addPacked(now, 1)
+}
+
+// addPacked adds n events to the packed word at the given time instant
+func (c *Counter) addPacked(now time.Time, n uint32) {
+	unit := c.packedUnit(now)
+	for {
+		old := c.packed.Load()
+		oldUnit, count := unpackWord(old)
+		if oldUnit != unit {
+			// A new time unit started, so the old count is out of the window
+			count = 0
+		}
+		if c.packed.CompareAndSwap(old, packWord(unit, count+n)) {
+			return
+		}
+	}
+}
+
+// packedCount returns the number of events in the time unit of the given
+// time instant
+func (c *Counter) packedCount(now time.Time) uint32 {
+	unit, count := unpackWord(c.packed.Load())
+	if unit != c.packedUnit(now) {
+		return 0
+	}
+	return count
+}
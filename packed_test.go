@@ -0,0 +1,87 @@
+package hops
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPackedCounter(t *testing.T) {
+	c := NewCounter(1, time.Second)
+	if !c.isPacked {
+		t.Fatal("expected a single-unit counter to use the packed representation")
+	}
+	if NewManualCounter(1, time.Second, time.Now()).isPacked {
+		t.Error("expected manual counters not to use the packed representation")
+	}
+	if NewCounter(2, time.Second).isPacked {
+		t.Error("expected larger windows not to use the packed representation")
+	}
+
+	start := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+	c.observePacked(start)
+	c.observePacked(start.Add(500 * time.Millisecond))
+
+	tests := map[string]struct {
+		at   time.Time
+		want uint32
+	}{
+		"same_unit":     {start.Add(999 * time.Millisecond), 2},
+		"next_unit":     {start.Add(time.Second), 0},
+		"previous_unit": {start.Add(-time.Second), 0},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := c.packedCount(tt.at); got != tt.want {
+				t.Errorf("expected: %d, got: %d", tt.want, got)
+			}
+		})
+	}
+
+	// A new unit starts from zero
+	c.observePacked(start.Add(time.Second))
+	if got := c.packedCount(start.Add(time.Second)); got != 1 {
+		t.Errorf("expected: 1, got: %d", got)
+	}
+}
+
+func TestPackedCounterConcurrently(t *testing.T) {
+	c := NewCounter(1, time.Hour)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.Observe()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := c.Value(); got != 5000 {
+		t.Errorf("expected: 5000, got: %d", got)
+	}
+	if s := c.Snapshot(); s.Total != 5000 || len(s.Counts) != 1 {
+		t.Errorf("expected a snapshot with a single unit of 5000 events, got: %+v", s)
+	}
+}
+
+func BenchmarkObserve(b *testing.B) {
+	for _, windowSize := range []int{1, 60} {
+		c := NewCounter(windowSize, time.Second)
+		name := "packed"
+		if windowSize > 1 {
+			name = "window"
+		}
+		b.Run(name, func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					c.Observe()
+				}
+			})
+		})
+	}
+}
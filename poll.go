@@ -0,0 +1,74 @@
+package hops
+
+import (
+	"sync"
+	"time"
+)
+
+// pollWindowSize is the number of samples kept by the gauges of
+// Registry.Poll
+const pollWindowSize = 60
+
+// Poll registers a gauge under the given name and samples fn into it right
+// away and then once every interval, in a separate goroutine. The gauge
+// keeps the samples of the last 60 intervals.
+//
+// This way, any value, such as the length of a queue or the number of open
+// connections, can be turned into a metric:
+//
+//	stop, err := r.Poll("queue.size", time.Second, func() float64 {
+//		return float64(q.Len())
+//	})
+//
+// If fn panics, the sample is skipped and polling goes on. Calling stop
+// ends the polling and unregisters the gauge.
+//
+// It fails with ErrAlreadyRegistered if the name is already taken.
+func (r *Registry) Poll(name string, interval time.Duration, fn func() float64) (stop func(), err error) {
+	g := NewGauge(pollWindowSize, interval)
+	if err := r.RegisterGauge(name, g); err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if v, ok := pollSafely(fn); ok {
+				g.Set(v)
+			}
+
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			r.mu.Lock()
+			// The name might have been taken over by another metric since
+			if r.gauges[name] == g {
+				delete(r.gauges, name)
+			}
+			r.mu.Unlock()
+		})
+	}, nil
+}
+
+// pollSafely calls fn, recovering from panics. It reports whether fn
+// returned normally.
+func pollSafely(fn func() float64) (v float64, ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	return fn(), true
+}
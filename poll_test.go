@@ -0,0 +1,50 @@
+package hops_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestRegistryPoll(t *testing.T) {
+	r := hops.NewRegistry()
+
+	var calls atomic.Int32
+	stop, err := r.Poll("queue.size", time.Millisecond, func() float64 {
+		n := calls.Add(1)
+		if n == 2 {
+			panic("flaky source")
+		}
+		return float64(n)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Poll("queue.size", time.Millisecond, func() float64 { return 0 }); !errors.Is(err, hops.ErrAlreadyRegistered) {
+		t.Errorf("expected: %v, got: %v", hops.ErrAlreadyRegistered, err)
+	}
+
+	// Keeps polling after a panic
+	deadline := time.Now().Add(time.Second)
+	for calls.Load() < 5 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected fn to be polled repeatedly")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	stats := r.Gauge("queue.size").Stats()
+	if stats.Min != 1 || stats.Count < 3 || stats.Count >= int(calls.Load()) {
+		t.Errorf("expected every sample but the panicking one, got: %+v after %d calls", stats, calls.Load())
+	}
+
+	stop()
+	stop()
+	if r.Gauge("queue.size") != nil {
+		t.Errorf("expected stop to unregister the gauge")
+	}
+}
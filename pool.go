@@ -0,0 +1,108 @@
+package hops
+
+import (
+	"sync"
+	"time"
+)
+
+// Pool hands out counters of the same window from a preallocated arena, and
+// takes them back when they're no longer needed. It's meant for tracking
+// short-lived entities in bulk, such as the request rate of each of tens of
+// thousands of connections, without allocating a counter for each of them:
+//
+//	p := hops.NewPool(50000, 10, time.Second)
+//
+//	c := p.Get()
+//	defer p.Put(c)
+//
+// Counters are reset when they're handed out, so they start empty with a
+// window that ends at the current time unit.
+//
+// It's safe to use the pool concurrently.
+type Pool struct {
+	// Guards free
+	mu sync.Mutex
+
+	// Counters ready to be handed out
+	free []*Counter
+
+	windowSize int
+	unit       time.Duration
+}
+
+// NewPool creates a pool of size counters with the given window size and
+// time unit. The counters and their windows are allocated up front, in two
+// contiguous blocks. When the pool runs out, Get allocates new counters.
+func NewPool(size, windowSize int, timeUnit time.Duration) *Pool {
+	arena := make([]Counter, size)
+	counts := make([]uint32, size*(windowSize-1))
+
+	p := &Pool{free: make([]*Counter, size), windowSize: windowSize, unit: timeUnit}
+	for i := range arena {
+		c := &arena[i]
+		lo, hi := i*(windowSize-1), (i+1)*(windowSize-1)
+		c.prevCounts = counts[lo:hi:hi]
+		c.isPacked = windowSize == 1
+		c.WindowSize = time.Duration(windowSize) * timeUnit
+		c.Unit = timeUnit
+		p.free[i] = c
+	}
+	return p
+}
+
+// Get returns an empty counter, taken from the pool if there are any left
+func (p *Pool) Get() *Counter {
+	now := time.Now()
+
+	p.mu.Lock()
+	n := len(p.free)
+	if n == 0 {
+		p.mu.Unlock()
+		return NewCounter(p.windowSize, p.unit)
+	}
+	c := p.free[n-1]
+	p.free[n-1] = nil
+	p.free = p.free[:n-1]
+	p.mu.Unlock()
+
+	c.reset(now)
+	return c
+}
+
+// Put returns a counter to the pool. The counter must not be used after
+// that, and any watcher of it, e.g. from OnIdle, must be stopped first.
+//
+// Manual counters, and counters with a different window, are dropped.
+func (p *Pool) Put(c *Counter) {
+	if c == nil || c.manual || c.Unit != p.unit || len(c.prevCounts) != p.windowSize-1 {
+		return
+	}
+
+	p.mu.Lock()
+	p.free = append(p.free, c)
+	p.mu.Unlock()
+}
+
+// Available returns the number of counters left in the pool
+func (p *Pool) Available() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.free)
+}
+
+// reset discards all the state of a regular counter, as if it was created
+// at the given time instant. The counter must not be in use.
+func (c *Counter) reset(now time.Time) {
+	c.resetWindow(now)
+	c.lastObserved.Store(0)
+
+	c.mu.Lock()
+	c.created = now
+	c.exemplars = nil
+	c.idleWatchers = nil
+	c.mu.Unlock()
+
+	c.skew.opts.Store(nil)
+	c.skew.drift.Store(0)
+	c.skew.count.Store(0)
+}
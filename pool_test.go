@@ -0,0 +1,74 @@
+package hops_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestPool(t *testing.T) {
+	p := hops.NewPool(2, 5, time.Minute)
+
+	c := p.Get()
+	c.Observe()
+	c.Observe()
+	p.Put(c)
+
+	reused := p.Get()
+	if reused != c {
+		t.Errorf("expected the counter to be reused")
+	}
+	if got := reused.Value(); got != 0 {
+		t.Errorf("expected an empty counter, got: %d", got)
+	}
+	if got := reused.LastObserved(); !got.IsZero() {
+		t.Errorf("expected no events, got: %v", got)
+	}
+	if reused.WindowSize != 5*time.Minute || reused.Unit != time.Minute {
+		t.Errorf("expected a 5m window with 1m unit, got: %v/%v", reused.WindowSize, reused.Unit)
+	}
+
+	// Counters from the arena don't share their windows
+	other := p.Get()
+	reused.Observe()
+	if got := other.Value(); got != 0 {
+		t.Errorf("expected: 0, got: %d", got)
+	}
+
+	if got := p.Available(); got != 0 {
+		t.Errorf("expected: 0, got: %d", got)
+	}
+	if extra := p.Get(); extra == nil || extra.Value() != 0 {
+		t.Errorf("expected a new counter once the pool runs out")
+	}
+}
+
+func TestPoolPutForeign(t *testing.T) {
+	p := hops.NewPool(0, 5, time.Minute)
+
+	p.Put(hops.NewCounter(10, time.Minute))
+	p.Put(hops.NewManualCounter(5, time.Minute, time.Now()))
+	p.Put(nil)
+	if got := p.Available(); got != 0 {
+		t.Errorf("expected foreign counters to be dropped, got: %d", got)
+	}
+
+	p.Put(hops.NewCounter(5, time.Minute))
+	if got := p.Available(); got != 1 {
+		t.Errorf("expected: 1, got: %d", got)
+	}
+}
+
+func TestPoolAllocs(t *testing.T) {
+	p := hops.NewPool(1, 60, time.Second)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		c := p.Get()
+		c.Observe()
+		p.Put(c)
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations, got: %v", allocs)
+	}
+}
@@ -0,0 +1,103 @@
+package hops
+
+import "math"
+
+// Priority of an event, used to decide which events to shed first as a
+// limiter approaches its limit
+type Priority int
+
+// Priorities, from the first to be shed to the last one. The share of the
+// limit of each priority is rounded up.
+const (
+	// PriorityLow events are denied once half of the limit is used
+	PriorityLow Priority = iota
+
+	// PriorityNormal events are denied once 75% of the limit is used
+	PriorityNormal
+
+	// PriorityHigh events are denied once 90% of the limit is used
+	PriorityHigh
+
+	// PriorityCritical events are denied only at the limit
+	PriorityCritical
+)
+
+// share returns the fraction of the limit that events of priority p may use
+func (p Priority) share() float64 {
+	switch {
+	case p <= PriorityLow:
+		return 0.5
+	case p == PriorityNormal:
+		return 0.75
+	case p == PriorityHigh:
+		return 0.9
+	default:
+		return 1
+	}
+}
+
+// capacity returns the part of limit that events of priority p may use,
+// rounded up so that small limits still let at least one event through
+func (p Priority) capacity(limit int) int {
+	if limit <= 0 {
+		return limit
+	}
+	return max(1, int(math.Ceil(float64(limit)*p.share())))
+}
+
+// PriorityLimiter is a limiter that sheds low priority events first
+type PriorityLimiter interface {
+	Limiter
+
+	// AllowPriority reports whether an event of the given priority may
+	// happen now, and records it if so
+	AllowPriority(p Priority) bool
+}
+
+// AllowPriority reports whether an event of the given priority may happen
+// now, and records it if so. As the number of events within the window
+// approaches the limit, low priority events are denied first, which keeps
+// room for high priority ones until the hard limit. Reserved events count
+// against the limit, as with Allow.
+func (l *WindowLimiter) AllowPriority(p Priority) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.flush(l.c.now())
+	if l.c.Value()+l.pendingEvents() >= p.capacity(l.limit) {
+		return false
+	}
+	l.c.Observe()
+	return true
+}
+
+// AllowPriority reports whether an event of the given priority may happen
+// now, and records it if so. As the number of events within the window
+// approaches the limit, low priority events are denied first, which keeps
+// room for high priority ones until the hard limit.
+func (l *SlidingLog) AllowPriority(p Priority) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.prune()
+	if len(l.log) >= p.capacity(l.limit) {
+		return false
+	}
+	l.log = append(l.log, now)
+	return true
+}
+
+// AllowPriority reports whether an event of the given priority for the
+// given key may happen now, and records it if so. Keys whose limiter doesn't
+// implement PriorityLimiter ignore the priority.
+func (k *KeyedLimiter) AllowPriority(key string, p Priority) bool {
+	l := k.Get(key)
+
+	var allowed bool
+	if pl, ok := l.(PriorityLimiter); ok {
+		allowed = pl.AllowPriority(p)
+	} else {
+		allowed = l.Allow()
+	}
+	return k.decide(key, allowed)
+}
@@ -0,0 +1,145 @@
+package hops_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestAllowPriority(t *testing.T) {
+	limiters := map[string]func() hops.PriorityLimiter{
+		"window_limiter": func() hops.PriorityLimiter {
+			return hops.NewWindowLimiter(hops.NewCounter(5, time.Minute), 20)
+		},
+		"sliding_log": func() hops.PriorityLimiter {
+			return hops.NewSlidingLog(20, time.Minute)
+		},
+	}
+
+	// Number of events of each priority allowed with a limit of 20
+	want := map[hops.Priority]int{
+		hops.PriorityLow:      10,
+		hops.PriorityNormal:   15,
+		hops.PriorityHigh:     18,
+		hops.PriorityCritical: 20,
+	}
+
+	for name, newLimiter := range limiters {
+		t.Run(name, func(t *testing.T) {
+			for p, n := range want {
+				l := newLimiter()
+				allowed := 0
+				for i := 0; i < 30; i++ {
+					if l.AllowPriority(p) {
+						allowed++
+					}
+				}
+				if allowed != n {
+					t.Errorf("priority %d: expected %d events, got: %d", p, n, allowed)
+				}
+			}
+		})
+	}
+}
+
+func TestAllowPrioritySmallLimit(t *testing.T) {
+	tests := map[string]struct {
+		limit int
+		want  map[hops.Priority]int
+	}{
+		"one": {1, map[hops.Priority]int{
+			hops.PriorityLow:      1,
+			hops.PriorityNormal:   1,
+			hops.PriorityHigh:     1,
+			hops.PriorityCritical: 1,
+		}},
+		"three": {3, map[hops.Priority]int{
+			hops.PriorityLow:      2,
+			hops.PriorityNormal:   3,
+			hops.PriorityHigh:     3,
+			hops.PriorityCritical: 3,
+		}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			for p, n := range tt.want {
+				l := hops.NewWindowLimiter(hops.NewCounter(5, time.Minute), tt.limit)
+				allowed := 0
+				for i := 0; i < 5; i++ {
+					if l.AllowPriority(p) {
+						allowed++
+					}
+				}
+				if allowed != n {
+					t.Errorf("priority %d: expected %d events, got: %d", p, n, allowed)
+				}
+			}
+		})
+	}
+}
+
+func TestAllowPriorityProtectsHighPriority(t *testing.T) {
+	l := hops.NewWindowLimiter(hops.NewCounter(5, time.Minute), 10)
+
+	// Low priority traffic fills its share
+	for l.AllowPriority(hops.PriorityLow) {
+	}
+
+	// High priority traffic still gets through, up to the hard limit
+	allowed := 0
+	for l.AllowPriority(hops.PriorityCritical) {
+		allowed++
+	}
+	if allowed != 5 {
+		t.Errorf("expected 5 critical events, got: %d", allowed)
+	}
+}
+
+func TestKeyedLimiterAllowPriority(t *testing.T) {
+	l := hops.NewKeyedLimiter(func() hops.Limiter {
+		return hops.NewWindowLimiter(hops.NewCounter(5, time.Minute), 2)
+	})
+
+	if !l.AllowPriority("alice", hops.PriorityLow) {
+		t.Errorf("expected the first low priority event to be allowed")
+	}
+	if l.AllowPriority("alice", hops.PriorityLow) {
+		t.Errorf("expected low priority events to be shed at half of the limit")
+	}
+	if !l.AllowPriority("alice", hops.PriorityCritical) {
+		t.Errorf("expected a critical event to be allowed")
+	}
+	if got := l.Metrics().Denied.Value(); got != 1 {
+		t.Errorf("expected denied: 1, got: %d", got)
+	}
+}
+
+func TestAllowPriorityWithReservations(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	c := hops.NewManualCounter(5, time.Second, start)
+	l := hops.NewWindowLimiter(c, 4)
+
+	// Fill the window, then reserve capacity for when it frees up
+	for i := 0; i < 4; i++ {
+		l.AllowPriority(hops.PriorityCritical)
+	}
+	r := l.Reserve()
+	if !r.OK() || r.Delay() != 5*time.Second {
+		t.Fatalf("expected a reservation in 5s, got: %v, %v", r.OK(), r.Delay())
+	}
+
+	// The reserved event counts against the limit once the window moves
+	c.Tick(start.Add(5 * time.Second))
+	allowed := 0
+	for l.AllowPriority(hops.PriorityCritical) {
+		allowed++
+	}
+	if allowed != 3 {
+		t.Errorf("expected 3 events next to the reserved one, got: %d", allowed)
+	}
+	if got := c.Value(); got != 4 {
+		t.Errorf("expected the reserved event to be recorded, got: %d events", got)
+	}
+}
@@ -0,0 +1,246 @@
+package hops
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Query evaluates an arithmetic expression over the counters of r, at the
+// current moment in time. The expression may contain:
+//
+//   - counter names, which stand for the number of events within the window
+//     of the counter, e.g. http.requests
+//   - gauge names, which stand for the most recent sample of the gauge,
+//     e.g. go.goroutines
+//   - sum(pattern), max(pattern) and avg(pattern), which fold the window
+//     totals of all counters whose name matches the glob pattern, as with
+//     Aggregate
+//   - rate(name), which is the number of events per second within the
+//     window of the counter
+//   - numbers, parentheses and the operators +, -, * and /
+//
+// For example, this returns the share of failed requests:
+//
+//	r.Query("sum(http.errors.*) / sum(http.requests.*)")
+//
+// Dividing by zero results in NaN. It fails with ErrInvalidQuery if the
+// expression is malformed or refers to a metric that isn't registered.
+func (r *Registry) Query(expr string) (float64, error) {
+	p := &queryParser{r: r, src: expr}
+	p.next()
+	v, err := p.expr()
+	if err != nil {
+		return 0, err
+	}
+	if p.tok != "" {
+		return 0, p.errorf("unexpected %q", p.tok)
+	}
+	return v, nil
+}
+
+// queryResult is the payload of a response of the query handler
+type queryResult struct {
+	Time  time.Time `json:"time"`
+	Query string    `json:"query"`
+
+	// Nil if the result isn't a number, e.g. after a division by zero
+	Value *float64 `json:"value"`
+}
+
+// NewQueryHandler returns an HTTP handler that evaluates the expression in
+// the "q" query parameter with r.Query, and responds with a JSON object:
+//
+//	GET /debug/hops/query?q=rate(http.requests)
+//	{"time":"2021-03-14T15:09:26Z","query":"rate(http.requests)","value":4.2}
+//
+// Malformed expressions are rejected with 400 Bad Request.
+func NewQueryHandler(r *Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		q := req.URL.Query().Get("q")
+		v, err := r.Query(q)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		result := queryResult{Time: time.Now(), Query: q}
+		if !math.IsNaN(v) && !math.IsInf(v, 0) {
+			result.Value = &v
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	})
+}
+
+// queryParser is a recursive descent parser that evaluates a query as it
+// goes, following this grammar:
+//
+//	expr   = term { ("+" | "-") term }
+//	term   = factor { ("*" | "/") factor }
+//	factor = number | name | name "(" pattern ")" | "(" expr ")" | "-" factor
+type queryParser struct {
+	r   *Registry
+	src string
+
+	// Position of the next token in src
+	pos int
+
+	// Current token, or the empty string at the end of the query
+	tok string
+}
+
+// next moves to the next token of the query
+func (p *queryParser) next() {
+	for p.pos < len(p.src) && p.src[p.pos] == ' ' {
+		p.pos++
+	}
+	if p.pos == len(p.src) {
+		p.tok = ""
+		return
+	}
+
+	start := p.pos
+	if strings.IndexByte("+-*/()", p.src[p.pos]) >= 0 {
+		p.pos++
+	} else {
+		for p.pos < len(p.src) && strings.IndexByte(" +-*/()", p.src[p.pos]) < 0 {
+			p.pos++
+		}
+	}
+	p.tok = p.src[start:p.pos]
+}
+
+func (p *queryParser) expr() (float64, error) {
+	v, err := p.term()
+	for err == nil && (p.tok == "+" || p.tok == "-") {
+		op := p.tok
+		p.next()
+
+		var w float64
+		if w, err = p.term(); op == "+" {
+			v += w
+		} else {
+			v -= w
+		}
+	}
+	return v, err
+}
+
+func (p *queryParser) term() (float64, error) {
+	v, err := p.factor()
+	for err == nil && (p.tok == "*" || p.tok == "/") {
+		op := p.tok
+		p.next()
+
+		var w float64
+		if w, err = p.factor(); op == "*" {
+			v *= w
+		} else if w == 0 {
+			v = math.NaN()
+		} else {
+			v /= w
+		}
+	}
+	return v, err
+}
+
+func (p *queryParser) factor() (float64, error) {
+	tok := p.tok
+	switch tok {
+	case "":
+		return 0, p.errorf("unexpected end of query")
+
+	case "(":
+		p.next()
+		v, err := p.expr()
+		if err != nil {
+			return 0, err
+		}
+		if p.tok != ")" {
+			return 0, p.errorf("missing closing parenthesis")
+		}
+		p.next()
+		return v, nil
+
+	case "-":
+		p.next()
+		v, err := p.factor()
+		return -v, err
+
+	case "+", "*", "/", ")":
+		return 0, p.errorf("unexpected %q", tok)
+	}
+
+	p.next()
+	if v, err := strconv.ParseFloat(tok, 64); err == nil {
+		return v, nil
+	}
+	if p.tok != "(" {
+		if g := p.r.Gauge(tok); g != nil {
+			return g.Stats().Last, nil
+		}
+		c, err := p.counter(tok)
+		if err != nil {
+			return 0, err
+		}
+		return float64(c.Value()), nil
+	}
+
+	// Function call. The argument is read as is, since glob patterns may
+	// contain operators.
+	end := strings.IndexByte(p.src[p.pos:], ')')
+	if end < 0 {
+		return 0, p.errorf("missing closing parenthesis")
+	}
+	arg := strings.TrimSpace(p.src[p.pos : p.pos+end])
+	if arg == "" || strings.ContainsAny(arg, " ()") {
+		return 0, p.errorf("expected a counter name in %s()", tok)
+	}
+	p.pos += end + 1
+	p.next()
+
+	var fn AggFunc
+	switch tok {
+	case "rate":
+		c, err := p.counter(arg)
+		if err != nil {
+			return 0, err
+		}
+		return float64(c.Value()) / c.WindowSize.Seconds(), nil
+	case "sum":
+		fn = AggSum
+	case "max":
+		fn = AggMax
+	case "avg":
+		fn = AggAvg
+	default:
+		return 0, p.errorf("unknown function %q", tok)
+	}
+
+	v, err := p.r.Aggregate(arg, fn)
+	if err != nil {
+		return 0, p.errorf("%v", err)
+	}
+	return v, nil
+}
+
+// counter returns the counter registered under the given name
+func (p *queryParser) counter(name string) (*Counter, error) {
+	if strings.ContainsAny(name, "*?[\\") {
+		return nil, p.errorf("patterns are only allowed in sum, max and avg: %q", name)
+	}
+	c := p.r.Get(name)
+	if c == nil {
+		return nil, p.errorf("unknown counter %q", name)
+	}
+	return c, nil
+}
+
+func (p *queryParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidQuery, fmt.Sprintf(format, args...))
+}
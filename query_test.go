@@ -0,0 +1,122 @@
+package hops_test
+
+import (
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func newQueryRegistry() *hops.Registry {
+	r := hops.NewRegistry()
+	for name, n := range map[string]int{
+		"http.requests.get":  30,
+		"http.requests.post": 10,
+		"http.errors":        8,
+		"jobs.failed":        0,
+	} {
+		c := hops.NewCounter(10, time.Second)
+		for i := 0; i < n; i++ {
+			c.Observe()
+		}
+		r.MustRegister(name, c)
+	}
+	return r
+}
+
+func TestRegistryQuery(t *testing.T) {
+	r := newQueryRegistry()
+
+	tests := map[string]struct {
+		expr string
+		want float64
+	}{
+		"name":        {"http.errors", 8},
+		"number":      {"2.5", 2.5},
+		"sum":         {"sum(http.requests.*)", 40},
+		"max":         {"max(http.requests.*)", 30},
+		"avg":         {"avg(http.requests.*)", 20},
+		"rate":        {"rate(http.requests.get)", 3},
+		"ratio":       {"http.errors / sum(http.requests.*)", 0.2},
+		"precedence":  {"1 + 2 * 3 - 4 / 2", 5},
+		"parentheses": {"(1 + 2) * 3", 9},
+		"negation":    {"-http.errors + 10", 2},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := r.Query(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("expected: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+
+	if got, _ := r.Query("http.errors / jobs.failed"); !math.IsNaN(got) {
+		t.Errorf("expected NaN after dividing by zero, got: %v", got)
+	}
+}
+
+func TestRegistryQueryInvalid(t *testing.T) {
+	r := newQueryRegistry()
+
+	for name, expr := range map[string]string{
+		"empty":            "",
+		"unknown_counter":  "grpc.requests",
+		"unknown_function": "min(http.*)",
+		"bare_pattern":     "http.*",
+		"bad_pattern":      "sum(http.[)",
+		"missing_paren":    "(1 + 2",
+		"missing_argument": "sum()",
+		"trailing":         "1 2",
+		"dangling_op":      "1 +",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := r.Query(expr); !errors.Is(err, hops.ErrInvalidQuery) {
+				t.Errorf("expected: %v, got: %v", hops.ErrInvalidQuery, err)
+			}
+		})
+	}
+}
+
+func TestQueryHandler(t *testing.T) {
+	srv := httptest.NewServer(hops.NewQueryHandler(newQueryRegistry()))
+	defer srv.Close()
+
+	tests := map[string]struct {
+		query      string
+		wantStatus int
+		wantBody   string
+	}{
+		"value":   {"sum(http.requests.*)", http.StatusOK, `"value":40}`},
+		"nan":     {"http.errors/jobs.failed", http.StatusOK, `"value":null}`},
+		"invalid": {"sum(", http.StatusBadRequest, "invalid query"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			resp, err := http.Get(srv.URL + "?q=" + tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("expected status: %d, got: %d", tt.wantStatus, resp.StatusCode)
+			}
+			if !strings.Contains(string(body), tt.wantBody) {
+				t.Errorf("expected body containing %s, got: %s", tt.wantBody, body)
+			}
+		})
+	}
+}
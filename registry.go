@@ -0,0 +1,256 @@
+package hops
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Registry is a collection of counters identified by name, so that they
+// can be looked up, exported and displayed together.
+//
+// It's safe to use the registry concurrently.
+type Registry struct {
+	mu       sync.RWMutex
+	counters map[string]*Counter
+	gauges   map[string]*Gauge
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		counters: make(map[string]*Counter),
+		gauges:   make(map[string]*Gauge),
+	}
+}
+
+// Register adds c to the registry under the given name.
+// It fails with ErrAlreadyRegistered if another counter or a gauge is
+// already registered under the same name.
+func (r *Registry) Register(name string, c *Counter) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.counters[name]; ok {
+		return fmt.Errorf("%w: %q", ErrAlreadyRegistered, name)
+	}
+	if _, ok := r.gauges[name]; ok {
+		return fmt.Errorf("%w: %q", ErrAlreadyRegistered, name)
+	}
+	r.counters[name] = c
+	return nil
+}
+
+// MustRegister is like Register but panics if the name is already taken
+func (r *Registry) MustRegister(name string, c *Counter) {
+	if err := r.Register(name, c); err != nil {
+		panic(err)
+	}
+}
+
+// Unregister removes the counter or gauge registered under the given name,
+// if any
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	delete(r.counters, name)
+	delete(r.gauges, name)
+	r.mu.Unlock()
+}
+
+// Get returns the counter registered under the given name, or nil if there
+// is no such counter
+func (r *Registry) Get(name string) *Counter {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.counters[name]
+}
+
+// Names returns the names of all registered counters, in sorted order
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.counters))
+	for name := range r.counters {
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+
+	sort.Strings(names)
+	return names
+}
+
+// AggFunc folds the window totals of several counters into a single value
+type AggFunc func(values []int) float64
+
+// Aggregation functions for Registry.Aggregate
+var (
+	// AggSum returns the sum of the values
+	AggSum AggFunc = func(values []int) float64 {
+		sum := 0
+		for _, v := range values {
+			sum += v
+		}
+		return float64(sum)
+	}
+
+	// AggMax returns the largest value, or 0 if there are no values
+	AggMax AggFunc = func(values []int) float64 {
+		largest := 0
+		for i, v := range values {
+			if i == 0 || v > largest {
+				largest = v
+			}
+		}
+		return float64(largest)
+	}
+
+	// AggAvg returns the average of the values, or 0 if there are no values
+	AggAvg AggFunc = func(values []int) float64 {
+		if len(values) == 0 {
+			return 0
+		}
+		return AggSum(values) / float64(len(values))
+	}
+)
+
+// Aggregate folds the window totals of all counters whose name matches the
+// glob pattern, using the syntax of path.Match. For example, this returns
+// the number of HTTP requests across all endpoints:
+//
+//	r.Aggregate("http.requests.*", hops.AggSum)
+//
+// It fails with path.ErrBadPattern if the pattern is malformed.
+func (r *Registry) Aggregate(pattern string, fn AggFunc) (float64, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return 0, err
+	}
+
+	return r.aggregate(func(name string) bool {
+		ok, _ := path.Match(pattern, name)
+		return ok
+	}, fn), nil
+}
+
+// AggregateRegexp is like Aggregate, but matches counter names against a
+// regular expression
+func (r *Registry) AggregateRegexp(re *regexp.Regexp, fn AggFunc) float64 {
+	return r.aggregate(re.MatchString, fn)
+}
+
+// aggregate folds the window totals of all counters whose name matches
+func (r *Registry) aggregate(match func(name string) bool, fn AggFunc) float64 {
+	var values []int
+	for _, name := range r.Names() {
+		if !match(name) {
+			continue
+		}
+		if c := r.Get(name); c != nil {
+			values = append(values, c.Value())
+		}
+	}
+	return fn(values)
+}
+
+// Serializes snapshotCounters, which holds the locks of many counters
+var snapshotAllMu sync.Mutex
+
+// RegistrySnapshot holds the windows of all counters of a registry at the
+// same moment in time
+type RegistrySnapshot struct {
+	Time time.Time
+
+	// Windows of the counters, by name
+	Counters map[string]Snapshot
+
+	// Statistics of the gauges, by name
+	Gauges map[string]GaugeStats
+}
+
+// SnapshotAll returns the windows of all registered counters at the same
+// moment in time, so exporters and ratios computed over several counters
+// don't mix values from slightly different instants, along with the
+// statistics of all registered gauges.
+//
+// All windows are moved to the same time instant and copied while holding
+// the locks of all counters, so none of them can hop in between. Each
+// counter sees that instant through its own clock, so manual counters are
+// captured at their own time instant, and skew handling applies.
+func (r *Registry) SnapshotAll() RegistrySnapshot {
+	now := time.Now()
+	snapshot := RegistrySnapshot{Time: now, Counters: make(map[string]Snapshot)}
+
+	var names []string
+	var counters []*Counter
+	for _, name := range r.Names() {
+		if c := r.Get(name); c != nil {
+			names = append(names, name)
+			counters = append(counters, c)
+		}
+	}
+
+	for i, s := range snapshotCounters(counters, now) {
+		snapshot.Counters[names[i]] = s
+	}
+
+	snapshot.Gauges = make(map[string]GaugeStats)
+	for _, name := range r.GaugeNames() {
+		if g := r.Gauge(name); g != nil {
+			snapshot.Gauges[name] = g.Stats()
+		}
+	}
+	return snapshot
+}
+
+// snapshotCounters returns the windows of the given counters at the same
+// moment in time, as seen by each counter, in the same order. Counters may
+// be repeated.
+func snapshotCounters(counters []*Counter, now time.Time) []Snapshot {
+	// A counter might be listed several times, or snapshotted by several
+	// goroutines, so lock each counter once, and only one snapshot at a
+	// time
+	snapshotAllMu.Lock()
+	defer snapshotAllMu.Unlock()
+
+	// Time instant of each counter, read before taking the locks since
+	// handling a skew may reset the window
+	at := make(map[*Counter]time.Time)
+	for _, c := range counters {
+		if _, ok := at[c]; ok {
+			continue
+		}
+		if c.manual {
+			at[c] = c.now()
+		} else {
+			at[c] = c.skewTime(now, wallDrift(c.created, now))
+		}
+	}
+
+	for c := range at {
+		c.mu.Lock()
+		if !c.isPacked {
+			c.moveWindowLocked(at[c])
+		}
+	}
+	snapshots := make([]Snapshot, len(counters))
+	for i, c := range counters {
+		s := &snapshots[i]
+		if c.isPacked {
+			count := c.packedCount(at[c])
+			*s = Snapshot{Start: at[c].Truncate(c.Unit), Unit: c.Unit, Counts: []uint32{count}, Total: int(count)}
+		} else {
+			*s = Snapshot{Start: c.windowStart, Unit: c.Unit}
+			s.Counts = append(append(s.Counts, c.prevCounts...), atomic.LoadUint32(&c.crtCount))
+			for _, n := range s.Counts {
+				s.Total += int(n)
+			}
+		}
+		c.appendExemplars(s)
+	}
+	for c := range at {
+		c.mu.Unlock()
+	}
+	return snapshots
+}
@@ -0,0 +1,123 @@
+package hops_test
+
+import (
+	"errors"
+	"path"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestRegistry(t *testing.T) {
+	r := hops.NewRegistry()
+	requests := hops.NewCounter(5, time.Minute)
+	failures := hops.NewCounter(5, time.Minute)
+
+	r.MustRegister("http.requests", requests)
+	r.MustRegister("http.errors", failures)
+
+	if err := r.Register("http.errors", requests); !errors.Is(err, hops.ErrAlreadyRegistered) {
+		t.Errorf("expected: %v, got: %v", hops.ErrAlreadyRegistered, err)
+	}
+	if got := r.Get("http.requests"); got != requests {
+		t.Errorf("Get returned the wrong counter")
+	}
+	if want := []string{"http.errors", "http.requests"}; !reflect.DeepEqual(r.Names(), want) {
+		t.Errorf("expected names: %v, got: %v", want, r.Names())
+	}
+
+	r.Unregister("http.errors")
+	if got := r.Get("http.errors"); got != nil {
+		t.Errorf("expected no counter after Unregister, got: %v", got)
+	}
+}
+
+func TestRegistryAggregate(t *testing.T) {
+	r := hops.NewRegistry()
+	for name, n := range map[string]int{
+		"http.requests.get":  3,
+		"http.requests.post": 1,
+		"http.errors":        7,
+	} {
+		c := hops.NewCounter(5, time.Minute)
+		for i := 0; i < n; i++ {
+			c.Observe()
+		}
+		r.MustRegister(name, c)
+	}
+
+	tests := map[string]struct {
+		pattern string
+		fn      hops.AggFunc
+		want    float64
+	}{
+		"sum":      {"http.requests.*", hops.AggSum, 4},
+		"max":      {"http.requests.*", hops.AggMax, 3},
+		"avg":      {"http.requests.*", hops.AggAvg, 2},
+		"all":      {"http.*", hops.AggSum, 11},
+		"no_match": {"grpc.*", hops.AggSum, 0},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := r.Aggregate(tt.pattern, tt.fn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("expected: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+
+	if got := r.AggregateRegexp(regexp.MustCompile(`\.(get|errors)$`), hops.AggSum); got != 10 {
+		t.Errorf("expected regexp sum: 10, got: %v", got)
+	}
+	if _, err := r.Aggregate("http.[", hops.AggSum); !errors.Is(err, path.ErrBadPattern) {
+		t.Errorf("expected: %v, got: %v", path.ErrBadPattern, err)
+	}
+}
+
+func TestRegistrySnapshotAll(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	r := hops.NewRegistry()
+	requests := hops.NewCounter(5, time.Minute)
+	failures := hops.NewCounter(5, time.Minute)
+	perSecond := hops.NewCounter(1, time.Second)
+	manual := hops.NewManualCounter(2, time.Second, start)
+	r.MustRegister("http.requests", requests)
+	r.MustRegister("http.errors", failures)
+	r.MustRegister("http.requests.per_second", perSecond)
+	r.MustRegister("jobs", manual)
+	r.MustRegister("jobs.alias", manual)
+
+	for i := 0; i < 4; i++ {
+		requests.Observe()
+	}
+	failures.Observe()
+	perSecond.Observe()
+	manual.Observe()
+	manual.Tick(start.Add(time.Second))
+
+	s := r.SnapshotAll()
+	if len(s.Counters) != 5 {
+		t.Fatalf("expected: 5 counters, got: %d", len(s.Counters))
+	}
+
+	a, b := s.Counters["http.requests"], s.Counters["http.errors"]
+	if !a.Start.Equal(b.Start) {
+		t.Errorf("expected windows lined up, got starts %v and %v", a.Start, b.Start)
+	}
+	if a.Total != 4 || b.Total != 1 {
+		t.Errorf("expected totals: 4 and 1, got: %d and %d", a.Total, b.Total)
+	}
+	if got := s.Counters["jobs"]; !reflect.DeepEqual(got.Counts, []uint32{1, 0}) || !got.Start.Equal(start) {
+		t.Errorf("expected the manual counter at its own time, got: %+v", got)
+	}
+	if got := s.Counters["http.requests.per_second"].Total; got > 1 {
+		t.Errorf("expected at most 1 event, got: %d", got)
+	}
+}
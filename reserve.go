@@ -0,0 +1,188 @@
+package hops
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+// InfDuration is the delay of a reservation that can't be fulfilled
+const InfDuration = time.Duration(math.MaxInt64)
+
+// Reservation holds capacity of a WindowLimiter for events that may happen
+// at a later time, as with the Reservation of golang.org/x/time/rate.
+//
+// It's safe to use the reservation concurrently.
+type Reservation struct {
+	l  *WindowLimiter
+	ok bool
+
+	// Time instant when the events may happen
+	at time.Time
+
+	// Number of events reserved
+	n int
+}
+
+// OK reports whether the limiter can fulfill the reservation. If it can't,
+// Delay returns InfDuration and the reservation holds no capacity.
+func (r *Reservation) OK() bool {
+	return r.ok
+}
+
+// Delay returns how long to wait from the current moment in time before
+// the reserved events may happen
+func (r *Reservation) Delay() time.Duration {
+	return r.DelayFrom(r.l.c.now())
+}
+
+// DelayFrom returns how long to wait from the given time instant before
+// the reserved events may happen
+func (r *Reservation) DelayFrom(now time.Time) time.Duration {
+	if !r.ok {
+		return InfDuration
+	}
+	return max(r.at.Sub(now), 0)
+}
+
+// Cancel gives the reserved capacity back to the limiter, so other events
+// can use it. It has no effect once the reserved time instant has passed,
+// since the events are recorded by then.
+func (r *Reservation) Cancel() {
+	if !r.ok {
+		return
+	}
+
+	r.l.mu.Lock()
+	defer r.l.mu.Unlock()
+
+	// Record the reservation instead if its time has come
+	r.l.flush(r.l.c.now())
+	if i := slices.Index(r.l.pending, r); i >= 0 {
+		r.l.pending = slices.Delete(r.l.pending, i, i+1)
+	}
+}
+
+// Reserve is shorthand for ReserveN(now, 1) at the current moment in time
+func (l *WindowLimiter) Reserve() *Reservation {
+	return l.ReserveN(l.c.now(), 1)
+}
+
+// ReserveN reserves capacity for n events that happen at now, or as soon
+// as the window has room for them. The events are recorded once their
+// time comes, unless the reservation is canceled before that. Callers that
+// can't wait the delay of the reservation must cancel it.
+//
+// The reservation isn't OK if n exceeds the limit. Reservations are
+// fulfilled in the order they're made.
+//
+// It mirrors the Limiter.ReserveN of golang.org/x/time/rate, so code written
+// against that package can switch to a sliding window:
+//
+//	r := l.ReserveN(time.Now(), 1)
+//	if !r.OK() {
+//		return errTooMany
+//	}
+//	time.Sleep(r.Delay())
+func (l *WindowLimiter) ReserveN(now time.Time, n int) *Reservation {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.flush(now)
+
+	r := &Reservation{l: l, n: n}
+	if n > l.limit {
+		return r
+	}
+
+	// Events within the window or reserved, by the time unit they happen in
+	type batch struct {
+		unit time.Time
+		n    int
+	}
+	var batches []batch
+	s := l.c.Snapshot()
+	for i, count := range s.Counts {
+		if count > 0 {
+			batches = append(batches, batch{s.Start.Add(time.Duration(i) * s.Unit), int(count)})
+		}
+	}
+	for _, p := range l.pending {
+		batches = append(batches, batch{p.at.Truncate(l.c.Unit), p.n})
+	}
+
+	// Reservations are fulfilled in order, so the events can only happen
+	// after the last reservation, either right away or once enough events
+	// fall out of the window
+	at := now
+	if len(l.pending) > 0 {
+		at = maxTime(at, l.pending[len(l.pending)-1].at)
+	}
+	candidates := []time.Time{at}
+	for _, b := range batches {
+		if expiry := b.unit.Add(l.c.WindowSize); expiry.After(at) {
+			candidates = append(candidates, expiry)
+		}
+	}
+	slices.SortFunc(candidates, time.Time.Compare)
+
+	for _, t := range candidates {
+		crtUnit := t.Truncate(l.c.Unit)
+		inWindow := 0
+		for _, b := range batches {
+			if !b.unit.After(crtUnit) && crtUnit.Sub(b.unit) < l.c.WindowSize {
+				inWindow += b.n
+			}
+		}
+		if inWindow+n <= l.limit {
+			r.ok, r.at = true, t
+			break
+		}
+	}
+
+	switch {
+	case !r.ok:
+	case r.at.After(now):
+		l.pending = append(l.pending, r)
+	default:
+		for i := 0; i < n; i++ {
+			l.c.Observe()
+		}
+	}
+	return r
+}
+
+// flush records the reserved events whose time has come, in the time unit
+// of their reservation. Events whose time unit already fell out of the
+// window are dropped. It must be called with mu held.
+func (l *WindowLimiter) flush(now time.Time) {
+	due := 0
+	var samples []BucketSample
+	for due < len(l.pending) && !l.pending[due].at.After(now) {
+		samples = append(samples, BucketSample{Start: l.pending[due].at, Count: uint32(l.pending[due].n)})
+		due++
+	}
+	if due == 0 {
+		return
+	}
+	l.c.ObserveBuckets(samples)
+	l.pending = slices.Delete(l.pending, 0, due)
+}
+
+// pendingEvents returns the number of reserved events still to be
+// recorded. It must be called with mu held.
+func (l *WindowLimiter) pendingEvents() int {
+	n := 0
+	for _, r := range l.pending {
+		n += r.n
+	}
+	return n
+}
+
+// maxTime returns the later of a and b
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
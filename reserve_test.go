@@ -0,0 +1,125 @@
+package hops_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestWindowLimiterReserveN(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	c := hops.NewManualCounter(3, time.Second, start)
+	l := hops.NewWindowLimiter(c, 2)
+
+	tests := []struct {
+		n         int
+		wantOK    bool
+		wantDelay time.Duration
+	}{
+		{1, true, 0},
+		{1, true, 0},
+		// Waits for the first events to fall out of the window
+		{1, true, 3 * time.Second},
+		// Waits for the previous reservation to fall out of the window
+		{2, true, 6 * time.Second},
+		// Over the limit
+		{3, false, hops.InfDuration},
+	}
+
+	reservations := make([]*hops.Reservation, len(tests))
+	for i, tt := range tests {
+		r := l.ReserveN(start, tt.n)
+		if r.OK() != tt.wantOK {
+			t.Errorf("reservation %d: expected ok: %v, got: %v", i, tt.wantOK, r.OK())
+		}
+		if got := r.DelayFrom(start); got != tt.wantDelay {
+			t.Errorf("reservation %d: expected delay: %v, got: %v", i, tt.wantDelay, got)
+		}
+		reservations[i] = r
+	}
+
+	if l.Allow() {
+		t.Errorf("expected the reserved capacity to be unavailable")
+	}
+
+	// The first pending reservation is recorded once its time comes, and
+	// canceling the second one frees up capacity
+	c.Tick(start.Add(3 * time.Second))
+	if got := reservations[2].Delay(); got != 0 {
+		t.Errorf("expected: 0, got: %v", got)
+	}
+	if l.Allow() {
+		t.Errorf("expected the capacity to be held by the pending reservation")
+	}
+	reservations[3].Cancel()
+	if !l.Allow() {
+		t.Errorf("expected the capacity of the canceled reservation to be available")
+	}
+	if got := c.Value(); got != 2 {
+		t.Errorf("expected: 2 recorded events, got: %d", got)
+	}
+}
+
+func TestWindowLimiterReserve(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	l := hops.NewWindowLimiter(hops.NewManualCounter(60, time.Second, start), 1)
+
+	if r := l.Reserve(); !r.OK() || r.Delay() != 0 {
+		t.Errorf("expected an immediate reservation, got delay: %v", r.Delay())
+	}
+	if r := l.Reserve(); r.Delay() != time.Minute {
+		t.Errorf("expected: %v, got: %v", time.Minute, r.Delay())
+	}
+	if got := l.Status().Remaining; got != 0 {
+		t.Errorf("expected: 0, got: %d", got)
+	}
+}
+
+func TestReservationRecordedInItsTimeUnit(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		// Time when the reservation is flushed, after it was due in 4s
+		flushAt time.Time
+
+		wantCounts []uint32
+	}{
+		"within_window": {start.Add(5 * time.Second), []uint32{0, 0, 1, 0}},
+		"out_of_window": {start.Add(10 * time.Second), []uint32{0, 0, 0, 0}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := hops.NewManualCounter(4, time.Second, start)
+			l := hops.NewWindowLimiter(c, 1)
+			l.Allow()
+			if r := l.Reserve(); r.Delay() != 4*time.Second {
+				t.Fatalf("expected a reservation in 4s, got: %v", r.Delay())
+			}
+
+			c.Tick(tt.flushAt)
+			l.Status()
+			if got := c.Snapshot().Counts; !slices.Equal(got, tt.wantCounts) {
+				t.Errorf("expected: %v, got: %v", tt.wantCounts, got)
+			}
+		})
+	}
+}
+
+func TestReservationCancelAfterDue(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	c := hops.NewManualCounter(10, time.Second, start)
+	l := hops.NewWindowLimiter(c, 1)
+
+	l.Allow()
+	r := l.Reserve()
+	c.Tick(start.Add(15 * time.Second))
+
+	// Canceling has no effect once the reservation is due
+	r.Cancel()
+	if got := c.Value(); got != 1 {
+		t.Errorf("expected the reserved event to be recorded, got: %d events", got)
+	}
+}
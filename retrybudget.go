@@ -0,0 +1,94 @@
+package hops
+
+import (
+	"sync"
+	"time"
+)
+
+// RetryBudget allows retries only while they stay under a share of the
+// original requests within a window, so retries can't pile up into a retry
+// storm when a downstream service is struggling.
+//
+// For example, this allows retrying up to 20% of the requests of the last
+// 10 seconds, plus 10 retries in that window to let low-traffic clients
+// retry at all:
+//
+//	b := hops.NewRetryBudget(10, time.Second, 0.2, 10)
+//	b.Request()
+//	if err != nil && b.TryRetry() {
+//		// retry
+//	}
+//
+// It's safe to use the budget concurrently.
+type RetryBudget struct {
+	// Serializes the check and the update of retries
+	mu sync.Mutex
+
+	requests *Counter
+	retries  *Counter
+
+	ratio      float64
+	minRetries int
+}
+
+// NewRetryBudget creates a budget that allows, within the given window,
+// ratio retries for each request plus minRetries retries
+func NewRetryBudget(windowSize int, timeUnit time.Duration, ratio float64, minRetries int) *RetryBudget {
+	return &RetryBudget{
+		requests:   NewCounter(windowSize, timeUnit),
+		retries:    NewCounter(windowSize, timeUnit),
+		ratio:      ratio,
+		minRetries: minRetries,
+	}
+}
+
+// NewManualRetryBudget creates a budget that doesn't follow the wall clock.
+// It only moves forward when the application calls Tick.
+func NewManualRetryBudget(windowSize int, timeUnit time.Duration, ratio float64, minRetries int, now time.Time) *RetryBudget {
+	return &RetryBudget{
+		requests:   NewManualCounter(windowSize, timeUnit, now),
+		retries:    NewManualCounter(windowSize, timeUnit, now),
+		ratio:      ratio,
+		minRetries: minRetries,
+	}
+}
+
+// Request records an original request, which adds to the budget.
+// Retries must not be recorded with Request.
+func (b *RetryBudget) Request() {
+	b.requests.Observe()
+}
+
+// TryRetry reports whether the budget allows a retry now, and records it
+// if so
+func (b *RetryBudget) TryRetry() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.retries.Value() >= b.allowed() {
+		return false
+	}
+	b.retries.Observe()
+	return true
+}
+
+// Remaining returns the number of retries still allowed now
+func (b *RetryBudget) Remaining() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return max(b.allowed()-b.retries.Value(), 0)
+}
+
+// Tick advances a manual budget to the given time instant.
+//
+// Tick has no effect on budgets that aren't created by
+// NewManualRetryBudget.
+func (b *RetryBudget) Tick(now time.Time) {
+	b.requests.Tick(now)
+	b.retries.Tick(now)
+}
+
+// allowed returns the number of retries allowed within the window
+func (b *RetryBudget) allowed() int {
+	return int(b.ratio*float64(b.requests.Value())) + b.minRetries
+}
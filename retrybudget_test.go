@@ -0,0 +1,66 @@
+package hops_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestRetryBudget(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		ratio      float64
+		minRetries int
+		requests   int
+		want       int
+	}{
+		"no_requests":       {0.2, 0, 0, 0},
+		"minimum":           {0.2, 3, 0, 3},
+		"share":             {0.2, 0, 50, 10},
+		"share_rounded":     {0.2, 0, 14, 2},
+		"share_and_minimum": {0.1, 2, 30, 5},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			b := hops.NewManualRetryBudget(10, time.Second, tt.ratio, tt.minRetries, start)
+			for i := 0; i < tt.requests; i++ {
+				b.Request()
+			}
+
+			if got := b.Remaining(); got != tt.want {
+				t.Errorf("expected remaining: %d, got: %d", tt.want, got)
+			}
+			retries := 0
+			for i := 0; i < 100; i++ {
+				if b.TryRetry() {
+					retries++
+				}
+			}
+			if retries != tt.want {
+				t.Errorf("expected: %d, got: %d", tt.want, retries)
+			}
+		})
+	}
+}
+
+func TestRetryBudgetRecovers(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	b := hops.NewManualRetryBudget(10, time.Second, 0.5, 0, start)
+	b.Request()
+	b.Request()
+
+	if !b.TryRetry() || b.TryRetry() {
+		t.Fatal("expected a single retry")
+	}
+
+	// The old retry falls out of the window, along with its requests
+	b.Tick(start.Add(10 * time.Second))
+	b.Request()
+	b.Request()
+	if !b.TryRetry() {
+		t.Errorf("expected the budget to recover")
+	}
+}
@@ -0,0 +1,126 @@
+package hops
+
+import (
+	"iter"
+	"time"
+)
+
+// Ring is a hopping window of buckets of any type, one for each time unit.
+// It's the building block of Gauge, Timer, Events, CoDel and
+// ConcurrencyLimiter, and can be used to build other windowed structures,
+// e.g. a window of sets of unique visitors:
+//
+//	r := hops.NewRing[map[string]bool](60, time.Second)
+//	b := r.Bucket(time.Now())
+//	if *b == nil {
+//		*b = make(map[string]bool)
+//	}
+//	(*b)[visitor] = true
+//
+// A bucket starts as the zero value of its type, and is reset to it when
+// the window hops past its time unit, so buckets are reused rather than
+// allocated as the window moves.
+//
+// Time units are counted from the Unix epoch, and the window ends at the
+// time unit of the time instant passed to All. Buckets of time units that
+// fell out of the window are skipped by All and reset by Bucket.
+//
+// The ring isn't safe to use concurrently; guard it with a mutex.
+//
+// Counter, FixedCounter and Gate keep their own windows and don't share
+// code with Ring.
+type Ring[T any] struct {
+	// slots[u % len(slots)] holds the bucket of time unit u, counted from
+	// the Unix epoch
+	slots []ringSlot[T]
+
+	unit time.Duration
+}
+
+// ringSlot holds the bucket of a time unit
+type ringSlot[T any] struct {
+	unit int64
+
+	// Whether the bucket was used since the slot was last reset
+	used bool
+
+	bucket T
+}
+
+// NewRing creates a ring with the given window size and time unit
+func NewRing[T any](windowSize int, timeUnit time.Duration) *Ring[T] {
+	return &Ring[T]{slots: make([]ringSlot[T], windowSize), unit: timeUnit}
+}
+
+// Unit returns the time unit of the ring
+func (r *Ring[T]) Unit() time.Duration {
+	return r.unit
+}
+
+// Size returns the number of time units of the window
+func (r *Ring[T]) Size() int {
+	return len(r.slots)
+}
+
+// Bucket returns the bucket of the time unit of t, which may be updated in
+// place. The bucket is reset to the zero value first if its slot holds an
+// older time unit.
+//
+// It returns nil if the slot already holds a newer time unit, i.e. t is
+// too old to be within the window.
+func (r *Ring[T]) Bucket(t time.Time) *T {
+	u := r.unitOf(t)
+	slot := &r.slots[r.index(u)]
+	if slot.used && slot.unit > u {
+		return nil
+	}
+	if !slot.used || slot.unit != u {
+		var zero T
+		slot.bucket = zero
+		slot.unit = u
+		slot.used = true
+	}
+	return &slot.bucket
+}
+
+// All returns a sequence of the buckets within the window that ends at the
+// time unit of now, with the start of their time unit, oldest first. Time
+// units whose bucket was never requested through Bucket are skipped.
+func (r *Ring[T]) All(now time.Time) iter.Seq2[time.Time, *T] {
+	return func(yield func(time.Time, *T) bool) {
+		crt := r.unitOf(now)
+		for u := crt - int64(len(r.slots)) + 1; u <= crt; u++ {
+			slot := &r.slots[r.index(u)]
+			if !slot.used || slot.unit != u {
+				continue
+			}
+			if !yield(time.Unix(0, u*int64(r.unit)), &slot.bucket) {
+				return
+			}
+		}
+	}
+}
+
+// Reset empties all the buckets of the ring
+func (r *Ring[T]) Reset() {
+	clear(r.slots)
+}
+
+// unitOf returns the time unit of t, counted from the Unix epoch. Time
+// instants before the epoch round down as well, to negative time units.
+func (r *Ring[T]) unitOf(t time.Time) int64 {
+	u := t.UnixNano() / int64(r.unit)
+	if t.UnixNano()%int64(r.unit) < 0 {
+		u--
+	}
+	return u
+}
+
+// index returns the slot of time unit u
+func (r *Ring[T]) index(u int64) int {
+	i := u % int64(len(r.slots))
+	if i < 0 {
+		i += int64(len(r.slots))
+	}
+	return int(i)
+}
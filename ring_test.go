@@ -0,0 +1,180 @@
+package hops_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestRing(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+
+	type sample struct {
+		second int
+		v      int
+	}
+	tests := map[string]struct {
+		added []sample
+		now   int
+		want  []sample
+	}{
+		"empty": {
+			nil, 0, nil,
+		},
+		"within_window": {
+			[]sample{{0, 1}, {0, 2}, {2, 3}}, 2,
+			[]sample{{0, 3}, {2, 3}},
+		},
+		"hopped": {
+			[]sample{{0, 1}, {1, 2}, {3, 3}}, 3,
+			[]sample{{1, 2}, {3, 3}},
+		},
+		"reused_slot": {
+			[]sample{{0, 1}, {3, 5}}, 3,
+			[]sample{{3, 5}},
+		},
+		"too_old": {
+			[]sample{{3, 5}, {0, 1}}, 3,
+			[]sample{{3, 5}},
+		},
+		"all_expired": {
+			[]sample{{0, 1}, {1, 2}}, 10,
+			nil,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := hops.NewRing[int](3, time.Second)
+			for _, s := range tt.added {
+				if b := r.Bucket(at(s.second)); b != nil {
+					*b += s.v
+				}
+			}
+
+			var got []sample
+			for unitStart, b := range r.All(at(tt.now)) {
+				got = append(got, sample{int(unitStart.Sub(start) / time.Second), *b})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRingHop(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	at := func(units int) time.Time { return start.Add(time.Duration(units) * time.Second) }
+
+	tests := map[string]struct {
+		unitsFromWindowEnd int
+		want               []int
+	}{
+		"one_unit": {
+			1,
+			[]int{2, 3, 4, 99},
+		},
+		"two_units": {
+			2,
+			[]int{3, 4, 99},
+		},
+		"keep_only_last_unit": {
+			4,
+			[]int{99},
+		},
+		"just_outside_of_the_window": {
+			5,
+			nil,
+		},
+		"way_outside_of_the_window": {
+			10,
+			nil,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := hops.NewRing[int](5, time.Second)
+			for i, v := range []int{1, 2, 3, 4, 99} {
+				*r.Bucket(at(i)) = v
+			}
+
+			var got []int
+			for _, b := range r.All(at(4 + tt.unitsFromWindowEnd)) {
+				got = append(got, *b)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRingRotate(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	r := hops.NewRing[int](5, time.Second)
+
+	// Go around the ring a couple of times
+	for i := range 13 {
+		*r.Bucket(start.Add(time.Duration(i) * time.Second)) = i
+	}
+
+	var got []int
+	for unitStart, b := range r.All(start.Add(12 * time.Second)) {
+		if want := start.Add(time.Duration(*b) * time.Second); !unitStart.Equal(want) {
+			t.Errorf("expected unit start: %v, got: %v", want, unitStart)
+		}
+		got = append(got, *b)
+	}
+	if want := []int{8, 9, 10, 11, 12}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected: %v, got: %v", want, got)
+	}
+
+	// Older time units are out of the window
+	if b := r.Bucket(start.Add(7 * time.Second)); b != nil {
+		t.Errorf("expected no bucket for a time unit out of the window, got: %v", *b)
+	}
+}
+
+func TestRingUnitBoundaries(t *testing.T) {
+	tests := map[string]time.Time{
+		"after_epoch":  time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC),
+		"before_epoch": time.Date(1969, 12, 31, 23, 59, 0, 0, time.UTC),
+	}
+
+	for name, start := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := hops.NewRing[int](3, time.Second)
+			*r.Bucket(start) += 1
+			*r.Bucket(start.Add(time.Second - time.Nanosecond)) += 1
+			*r.Bucket(start.Add(time.Second)) += 1
+
+			var got []int
+			for _, b := range r.All(start.Add(time.Second)) {
+				got = append(got, *b)
+			}
+			if want := []int{2, 1}; !reflect.DeepEqual(got, want) {
+				t.Errorf("expected: %v, got: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestRingReset(t *testing.T) {
+	now := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+	r := hops.NewRing[[]string](5, time.Minute)
+	b := r.Bucket(now)
+	*b = append(*b, "a")
+
+	r.Reset()
+	for _, b := range r.All(now) {
+		t.Errorf("expected no buckets after a reset, got: %v", *b)
+	}
+	if b := r.Bucket(now); *b != nil {
+		t.Errorf("expected an empty bucket, got: %v", *b)
+	}
+}
@@ -0,0 +1,162 @@
+package hops
+
+import (
+	"context"
+	"math"
+	"runtime/metrics"
+	"time"
+)
+
+// Names of the runtime/metrics samples read by RuntimeCollector
+const (
+	goroutinesMetric = "/sched/goroutines:goroutines"
+	heapMetric       = "/memory/classes/heap/objects:bytes"
+	gcCyclesMetric   = "/gc/cycles/total:gc-cycles"
+	gcPausesMetric   = "/sched/pauses/total/gc:seconds"
+)
+
+// RuntimeCollector periodically samples metrics of the Go runtime into
+// hopping windows, so questions like "how many GC cycles ran in the last 5
+// minutes" are answered by the same registry and exporters as the
+// application's own counters.
+type RuntimeCollector struct {
+	// Number of live goroutines
+	Goroutines *Gauge
+
+	// Bytes of heap memory occupied by objects, live or not yet swept
+	HeapBytes *Gauge
+
+	// Completed GC cycles
+	GCCycles *Counter
+
+	// Durations of the stop-the-world pauses of the GC
+	GCPauses *Timer
+
+	// Number of stop-the-world pauses of the GC, i.e. GCPauses.Count(),
+	// as a counter so it can be registered
+	GCPauseCount *Counter
+
+	samples []metrics.Sample
+
+	// Cumulative values at the previous collection
+	lastCycles uint64
+	lastPauses []uint64
+}
+
+// NewRuntimeCollector creates a collector whose counters, gauges and timer
+// have the given window size and time unit. Only events after its creation
+// are counted.
+func NewRuntimeCollector(windowSize int, timeUnit time.Duration) *RuntimeCollector {
+	c := &RuntimeCollector{
+		Goroutines:   NewGauge(windowSize, timeUnit),
+		HeapBytes:    NewGauge(windowSize, timeUnit),
+		GCCycles:     NewCounter(windowSize, timeUnit),
+		GCPauses:     NewTimer(windowSize, timeUnit),
+		GCPauseCount: NewCounter(windowSize, timeUnit),
+		samples: []metrics.Sample{
+			{Name: goroutinesMetric},
+			{Name: heapMetric},
+			{Name: gcCyclesMetric},
+			{Name: gcPausesMetric},
+		},
+	}
+
+	// Start counting from the current totals
+	metrics.Read(c.samples)
+	if s := c.sample(gcCyclesMetric); s.Value.Kind() == metrics.KindUint64 {
+		c.lastCycles = s.Value.Uint64()
+	}
+	if s := c.sample(gcPausesMetric); s.Value.Kind() == metrics.KindFloat64Histogram {
+		c.lastPauses = append([]uint64(nil), s.Value.Float64Histogram().Counts...)
+	}
+	return c
+}
+
+// Register adds the counters and gauges of the collector to r, under the
+// names go.goroutines, go.heap.bytes, go.gc.cycles and go.gc.pauses. The
+// registry doesn't hold timers, so the durations of GCPauses are only
+// available from the collector.
+func (c *RuntimeCollector) Register(r *Registry) error {
+	if err := r.RegisterGauge("go.goroutines", c.Goroutines); err != nil {
+		return err
+	}
+	if err := r.RegisterGauge("go.heap.bytes", c.HeapBytes); err != nil {
+		return err
+	}
+	if err := r.Register("go.gc.cycles", c.GCCycles); err != nil {
+		return err
+	}
+	return r.Register("go.gc.pauses", c.GCPauseCount)
+}
+
+// Run collects the metrics once every interval, until ctx is done
+func (c *RuntimeCollector) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		c.Collect()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Collect samples the metrics of the runtime once. Metrics not supported
+// by the runtime are skipped.
+func (c *RuntimeCollector) Collect() {
+	metrics.Read(c.samples)
+
+	if s := c.sample(goroutinesMetric); s.Value.Kind() == metrics.KindUint64 {
+		c.Goroutines.Set(float64(s.Value.Uint64()))
+	}
+	if s := c.sample(heapMetric); s.Value.Kind() == metrics.KindUint64 {
+		c.HeapBytes.Set(float64(s.Value.Uint64()))
+	}
+
+	if s := c.sample(gcCyclesMetric); s.Value.Kind() == metrics.KindUint64 {
+		for n := s.Value.Uint64(); c.lastCycles < n; c.lastCycles++ {
+			c.GCCycles.Observe()
+		}
+	}
+
+	if s := c.sample(gcPausesMetric); s.Value.Kind() == metrics.KindFloat64Histogram {
+		h := s.Value.Float64Histogram()
+		if len(c.lastPauses) != len(h.Counts) {
+			c.lastPauses = make([]uint64, len(h.Counts))
+		}
+		for i, n := range h.Counts {
+			// Record the new pauses of each bucket at its finite bound
+			d := pauseDuration(h.Buckets[i], h.Buckets[i+1])
+			for ; c.lastPauses[i] < n; c.lastPauses[i]++ {
+				c.GCPauses.Record(d)
+				c.GCPauseCount.Observe()
+			}
+		}
+	}
+}
+
+// sample returns the sample with the given name
+func (c *RuntimeCollector) sample(name string) metrics.Sample {
+	for _, s := range c.samples {
+		if s.Name == name {
+			return s
+		}
+	}
+	return metrics.Sample{}
+}
+
+// pauseDuration returns a representative duration of the histogram bucket
+// of seconds between lo and hi, which may be infinite
+func pauseDuration(lo, hi float64) time.Duration {
+	switch {
+	case math.IsInf(lo, -1):
+		lo = 0
+	case math.IsInf(hi, 1):
+		hi = lo
+	}
+	return time.Duration((lo + hi) / 2 * float64(time.Second))
+}
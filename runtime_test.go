@@ -0,0 +1,37 @@
+package hops_test
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestRuntimeCollector(t *testing.T) {
+	c := hops.NewRuntimeCollector(5, time.Minute)
+	r := hops.NewRegistry()
+	if err := c.Register(r); err != nil {
+		t.Fatal(err)
+	}
+
+	runtime.GC()
+	runtime.GC()
+	c.Collect()
+
+	if got := r.Gauge("go.goroutines").Stats().Last; got < 1 {
+		t.Errorf("expected at least one goroutine, got: %v", got)
+	}
+	if got := r.Gauge("go.heap.bytes").Stats().Last; got <= 0 {
+		t.Errorf("expected a positive heap size, got: %v", got)
+	}
+	if got := r.Get("go.gc.cycles").Value(); got < 2 {
+		t.Errorf("expected at least 2 GC cycles, got: %d", got)
+	}
+	if got := c.GCPauses.Count(); got < 2 {
+		t.Errorf("expected at least 2 GC pauses, got: %d", got)
+	}
+	if got, want := r.Get("go.gc.pauses").Value(), c.GCPauses.Count(); got != want {
+		t.Errorf("expected: %d, got: %d", want, got)
+	}
+}
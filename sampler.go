@@ -0,0 +1,153 @@
+package hops
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Sampler decides which traces to sample, admitting at most a given number
+// of them within a hopping window, overall or for each key (e.g. each
+// endpoint). Tracing backends aren't flooded during traffic spikes, while
+// quiet periods get every trace sampled.
+//
+// Keys without traces sampled within the window are forgotten, so the
+// sampler doesn't grow with every key it has ever seen.
+//
+// It's safe to use the sampler concurrently.
+type Sampler struct {
+	limit      int
+	windowSize int
+	unit       time.Duration
+
+	// Set for samplers that are advanced explicitly through Tick
+	manual bool
+
+	// Guards keys, lastSweep and tickTime
+	mu sync.Mutex
+
+	// Budget of each key of ShouldSampleKey, prefixed with "key:". The
+	// budget of ShouldSample is under the empty key.
+	keys map[string]samplerBudget
+
+	// Time unit when the idle keys were last removed, counted from the
+	// Unix epoch
+	lastSweep int64
+
+	// Latest time instant passed to Tick
+	tickTime time.Time
+}
+
+// samplerBudget holds the sampled traces of a key
+type samplerBudget struct {
+	c *Counter
+	l *WindowLimiter
+}
+
+// NewSampler creates a sampler that admits at most limit traces within
+// the given window.
+//
+// For example, NewSampler(100, 60, time.Second) samples at most 100 traces
+// in the last minute.
+func NewSampler(limit, windowSize int, timeUnit time.Duration) *Sampler {
+	return &Sampler{
+		limit:      limit,
+		windowSize: windowSize,
+		unit:       timeUnit,
+		keys:       make(map[string]samplerBudget),
+	}
+}
+
+// NewRateSampler creates a sampler that admits traces at the given average
+// rate per second, within the given window. Bursts are allowed as long as
+// the window stays within its budget.
+//
+// For example, NewRateSampler(2, 60, time.Second) samples at most 120
+// traces in the last minute.
+func NewRateSampler(rate float64, windowSize int, timeUnit time.Duration) *Sampler {
+	window := time.Duration(windowSize) * timeUnit
+	return NewSampler(int(math.Ceil(rate*window.Seconds())), windowSize, timeUnit)
+}
+
+// NewManualSampler creates a sampler that doesn't follow the wall clock.
+// It only moves forward when the application calls Tick.
+func NewManualSampler(limit, windowSize int, timeUnit time.Duration, now time.Time) *Sampler {
+	s := NewSampler(limit, windowSize, timeUnit)
+	s.manual = true
+	s.tickTime = now
+	return s
+}
+
+// ShouldSample reports whether a trace should be sampled, and counts it
+// against the budget of the window if so
+func (s *Sampler) ShouldSample() bool {
+	return s.budget("").l.Allow()
+}
+
+// ShouldSampleKey is like ShouldSample, but each key has its own budget,
+// separate from the one of ShouldSample
+func (s *Sampler) ShouldSampleKey(key string) bool {
+	return s.budget("key:" + key).l.Allow()
+}
+
+// Tick advances a manual sampler to the given time instant. Time instants
+// older than the latest one passed to Tick are ignored.
+//
+// Tick has no effect on samplers that aren't created by NewManualSampler.
+func (s *Sampler) Tick(now time.Time) {
+	if !s.manual {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.After(s.tickTime) {
+		s.tickTime = now
+	}
+	for _, b := range s.keys {
+		b.c.Tick(now)
+	}
+}
+
+// budget returns the budget of the given key, creating it if needed
+func (s *Sampler) budget(key string) samplerBudget {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep()
+	if b, ok := s.keys[key]; ok {
+		return b
+	}
+
+	var c *Counter
+	if s.manual {
+		c = NewManualCounter(s.windowSize, s.unit, s.tickTime)
+	} else {
+		c = NewCounter(s.windowSize, s.unit)
+	}
+	b := samplerBudget{c: c, l: NewWindowLimiter(c, s.limit)}
+	s.keys[key] = b
+	return b
+}
+
+// sweep removes the keys without traces within the window, once per time
+// unit. Their budget is full, so forgetting them doesn't change any
+// decision. It must be called with mu held.
+func (s *Sampler) sweep() {
+	now := s.tickTime
+	if !s.manual {
+		now = time.Now()
+	}
+	crtUnit := now.UnixNano() / int64(s.unit)
+	if crtUnit == s.lastSweep {
+		return
+	}
+	s.lastSweep = crtUnit
+
+	for key, b := range s.keys {
+		if b.c.Value() == 0 {
+			delete(s.keys, key)
+		}
+	}
+}
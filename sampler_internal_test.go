@@ -0,0 +1,28 @@
+package hops
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSamplerForgetsIdleKeys(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	s := NewManualSampler(1, 2, time.Minute, start)
+
+	for i := 0; i < 100; i++ {
+		s.ShouldSampleKey(fmt.Sprintf("/users/%d", i))
+	}
+
+	s.Tick(start.Add(time.Minute))
+	s.ShouldSampleKey("/checkout")
+	if got := len(s.keys); got != 101 {
+		t.Errorf("expected the keys to be kept within the window: 101, got: %d", got)
+	}
+
+	s.Tick(start.Add(2 * time.Minute))
+	s.ShouldSample()
+	if got := len(s.keys); got != 2 {
+		t.Errorf("expected only /checkout and the overall budget, got: %d keys", got)
+	}
+}
@@ -0,0 +1,78 @@
+package hops_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestSampler(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	s := hops.NewManualSampler(3, 2, time.Second, start)
+
+	tests := []struct {
+		advance time.Duration
+		traces  int
+		want    int
+	}{
+		// A spike is capped
+		{0, 10, 3},
+		{time.Second, 10, 0},
+		// The budget is back once the spike leaves the window
+		{time.Second, 2, 2},
+		// Quiet periods get every trace sampled
+		{2 * time.Second, 1, 1},
+		{time.Second, 1, 1},
+	}
+
+	now := start
+	for i, tt := range tests {
+		now = now.Add(tt.advance)
+		s.Tick(now)
+
+		sampled := 0
+		for j := 0; j < tt.traces; j++ {
+			if s.ShouldSample() {
+				sampled++
+			}
+		}
+		if sampled != tt.want {
+			t.Errorf("step %d: expected: %d sampled, got: %d", i, tt.want, sampled)
+		}
+	}
+}
+
+func TestSamplerKeys(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	s := hops.NewManualSampler(1, 1, time.Minute, start)
+
+	if !s.ShouldSampleKey("/checkout") || s.ShouldSampleKey("/checkout") {
+		t.Errorf("expected 1 trace sampled for /checkout")
+	}
+	if !s.ShouldSampleKey("/search") {
+		t.Errorf("expected keys to have separate budgets")
+	}
+	if !s.ShouldSample() {
+		t.Errorf("expected the overall budget to be separate from the keys")
+	}
+
+	s.Tick(start.Add(time.Minute))
+	if !s.ShouldSampleKey("/checkout") {
+		t.Errorf("expected the budget of /checkout to be back")
+	}
+}
+
+func TestNewRateSampler(t *testing.T) {
+	s := hops.NewRateSampler(0.5, 10, time.Second)
+
+	sampled := 0
+	for i := 0; i < 100; i++ {
+		if s.ShouldSample() {
+			sampled++
+		}
+	}
+	if sampled != 5 {
+		t.Errorf("expected: 5 sampled, got: %d", sampled)
+	}
+}
@@ -0,0 +1,144 @@
+package hops_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+// allowed returns how many events l allows out of n
+func allowed(l hops.Limiter, n int) int {
+	count := 0
+	for i := 0; i < n; i++ {
+		if l.Allow() {
+			count++
+		}
+	}
+	return count
+}
+
+func TestSetLimitKeepsHistory(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		limiter interface {
+			hops.Limiter
+			SetLimit(int)
+		}
+	}{
+		"window_limiter": {hops.NewWindowLimiter(hops.NewManualCounter(5, time.Second, start), 5)},
+		"sliding_log":    {hops.NewManualSlidingLog(5, 5*time.Second, start)},
+		"gcra":           {hops.NewManualGCRA(1, time.Second, 5, start)},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			l := tt.limiter
+			if got := allowed(l, 3); got != 3 {
+				t.Fatalf("expected 3 events, got: %d", got)
+			}
+
+			if gcra, ok := l.(*hops.GCRA); ok {
+				// The burst is the number of events allowed at once
+				gcra.SetBurst(4)
+			} else {
+				l.SetLimit(4)
+			}
+
+			// The events already recorded count against the new limit
+			if got := allowed(l, 5); got != 1 {
+				t.Errorf("expected 1 more event, got: %d", got)
+			}
+		})
+	}
+}
+
+func TestTokenBucketSetRateAndBurst(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	b := hops.NewManualTokenBucket(1, 10, start)
+
+	b.SetBurst(4)
+	if got := allowed(b, 10); got != 4 {
+		t.Errorf("expected the tokens to be capped at the new burst: 4, got: %d", got)
+	}
+
+	b.SetRate(3)
+	b.Tick(start.Add(time.Second))
+	if got := allowed(b, 10); got != 3 {
+		t.Errorf("expected 3 tokens refilled at the new rate, got: %d", got)
+	}
+}
+
+func TestKeyedLimiterSetLimit(t *testing.T) {
+	l := hops.NewKeyedLimiter(func() hops.Limiter {
+		return hops.NewWindowLimiter(hops.NewCounter(5, time.Minute), 1)
+	})
+	l.Allow("alice")
+
+	l.SetLimit(3)
+
+	if got := allowed(l.Get("alice"), 5); got != 2 {
+		t.Errorf("expected 2 more events for an existing key, got: %d", got)
+	}
+	if got := allowed(l.Get("bob"), 5); got != 3 {
+		t.Errorf("expected the new limit for a new key: 3, got: %d", got)
+	}
+}
+
+func TestTokenBucketSetLimit(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	b := hops.NewManualTokenBucket(5, 10, start)
+
+	b.SetLimit(4)
+	if got := allowed(b, 10); got != 4 {
+		t.Errorf("expected the tokens to be capped at the new limit: 4, got: %d", got)
+	}
+
+	// The bucket still refills in 2 seconds
+	b.Tick(start.Add(time.Second))
+	if got := allowed(b, 10); got != 2 {
+		t.Errorf("expected 2 tokens refilled, got: %d", got)
+	}
+
+	b.SetLimit(0)
+	b.Tick(start.Add(time.Minute))
+	if got := allowed(b, 10); got != 0 {
+		t.Errorf("expected all events to be denied, got: %d", got)
+	}
+
+	b.SetLimit(8)
+	b.Tick(start.Add(time.Minute + time.Second))
+	if got := allowed(b, 10); got != 4 {
+		t.Errorf("expected the refill time to be restored, got: %d tokens", got)
+	}
+}
+
+func TestKeyedLimiterSetLimitAlgorithms(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		newLimiter func() hops.Limiter
+	}{
+		"token_bucket": {func() hops.Limiter { return hops.NewManualTokenBucket(1, 5, start) }},
+		"sliding_log":  {func() hops.Limiter { return hops.NewManualSlidingLog(5, time.Minute, start) }},
+		"gcra":         {func() hops.Limiter { return hops.NewManualGCRA(5, time.Minute, 5, start) }},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			l := hops.NewKeyedLimiter(tt.newLimiter)
+			l.Get("alice")
+
+			// Only the latest limit applies, to existing and new keys
+			for _, limit := range []int{3, 2, 0} {
+				l.SetLimit(limit)
+			}
+			for _, key := range []string{"alice", "bob"} {
+				if got := allowed(l.Get(key), 5); got != 0 {
+					t.Errorf("expected all events of %s to be denied, got: %d", key, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package hops
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// WindowCounter counts events within a window, e.g. Counter or
+// FixedCounter
+type WindowCounter interface {
+	// Observe adds an event to the window at the current moment in time
+	Observe()
+
+	// Value returns the number of events within the window
+	Value() int
+}
+
+// Divergence records the values of a shadowed counter and its shadow
+// disagreeing
+type Divergence struct {
+	Time time.Time
+
+	Primary int
+	Shadow  int
+}
+
+// ShadowStats summarizes the comparisons made by a Shadow
+type ShadowStats struct {
+	// Number of times the values were compared
+	Checks int
+
+	// Number of comparisons where the values differed by more than the
+	// tolerance
+	Divergences int
+
+	// Largest absolute difference between the values
+	MaxDiff int
+}
+
+// Shadow feeds every event to two counters, a primary one and a shadow
+// one, and compares their values every time Value is called. It's meant
+// for validating a new counter implementation under real traffic before
+// switching to it:
+//
+//	c := hops.NewShadow(
+//		hops.NewCounter(5, time.Minute),
+//		hops.NewFixedCounter[[5]uint32](time.Minute),
+//		0, func(d hops.Divergence) { log.Printf("counters diverged: %+v", d) },
+//	)
+//
+// Only the primary counter's value is returned, so the shadow can't affect
+// the application.
+//
+// It's safe to use concurrently if both counters are. Events observed
+// between reading the two values make them differ, so allow some tolerance
+// under concurrent use.
+type Shadow struct {
+	primary WindowCounter
+	shadow  WindowCounter
+
+	tolerance int
+	onDiverge func(Divergence)
+
+	checks      atomic.Int64
+	divergences atomic.Int64
+	maxDiff     atomic.Int64
+}
+
+// NewShadow creates a shadow of primary. fn is called, if set, every time
+// the values differ by more than tolerance. It must not block.
+func NewShadow(primary, shadow WindowCounter, tolerance int, fn func(Divergence)) *Shadow {
+	return &Shadow{primary: primary, shadow: shadow, tolerance: tolerance, onDiverge: fn}
+}
+
+// Observe adds an event to both counters
+func (s *Shadow) Observe() {
+	s.primary.Observe()
+	s.shadow.Observe()
+}
+
+// Value returns the value of the primary counter, after comparing it with
+// the value of the shadow
+func (s *Shadow) Value() int {
+	primary := s.primary.Value()
+	shadow := s.shadow.Value()
+
+	diff := int64(primary - shadow)
+	if diff < 0 {
+		diff = -diff
+	}
+	s.checks.Add(1)
+	for {
+		old := s.maxDiff.Load()
+		if old >= diff || s.maxDiff.CompareAndSwap(old, diff) {
+			break
+		}
+	}
+
+	if diff > int64(s.tolerance) {
+		s.divergences.Add(1)
+		if s.onDiverge != nil {
+			s.onDiverge(Divergence{Time: time.Now(), Primary: primary, Shadow: shadow})
+		}
+	}
+	return primary
+}
+
+// Stats returns a summary of the comparisons made so far
+func (s *Shadow) Stats() ShadowStats {
+	return ShadowStats{
+		Checks:      int(s.checks.Load()),
+		Divergences: int(s.divergences.Load()),
+		MaxDiff:     int(s.maxDiff.Load()),
+	}
+}
@@ -0,0 +1,62 @@
+package hops_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+// lossyCounter drops every other event
+type lossyCounter struct {
+	events int
+}
+
+func (c *lossyCounter) Observe()   { c.events++ }
+func (c *lossyCounter) Value() int { return c.events / 2 }
+
+func TestShadow(t *testing.T) {
+	tests := map[string]struct {
+		shadow          hops.WindowCounter
+		tolerance       int
+		wantDivergences int
+		wantMaxDiff     int
+	}{
+		"agreeing": {
+			shadow: hops.NewFixedCounter[[5]uint32](time.Hour),
+		},
+		"diverging": {
+			shadow:          &lossyCounter{},
+			wantDivergences: 4,
+			wantMaxDiff:     2,
+		},
+		"within_tolerance": {
+			shadow:      &lossyCounter{},
+			tolerance:   2,
+			wantMaxDiff: 2,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var diverged []hops.Divergence
+			s := hops.NewShadow(hops.NewCounter(5, time.Hour), tt.shadow, tt.tolerance,
+				func(d hops.Divergence) { diverged = append(diverged, d) })
+
+			for i := 0; i < 4; i++ {
+				s.Observe()
+				if got := s.Value(); got != i+1 {
+					t.Errorf("expected the primary value: %d, got: %d", i+1, got)
+				}
+			}
+
+			want := hops.ShadowStats{Checks: 4, Divergences: tt.wantDivergences, MaxDiff: tt.wantMaxDiff}
+			if got := s.Stats(); got != want {
+				t.Errorf("expected: %+v, got: %+v", want, got)
+			}
+			if len(diverged) != tt.wantDivergences {
+				t.Errorf("expected: %d divergences reported, got: %d", tt.wantDivergences, len(diverged))
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package hops
+
+import (
+	"fmt"
+	"path"
+	"time"
+)
+
+// Silence suppresses the notifications of the alert rules it matches for a
+// period of time, e.g. during planned maintenance. The rules are still
+// evaluated, and Alerter.Alerts reports them as silenced.
+type Silence struct {
+	// Glob pattern, using the syntax of path.Match, matched against the
+	// name and the metric of each rule. For example, "db.*" silences the
+	// rules named after db counters, and the rules on a single db counter.
+	Match string
+
+	// Period during which the silence is active. A zero Start means it's
+	// active right away.
+	Start time.Time
+	End   time.Time
+
+	// Why the rules are silenced, and by whom
+	Comment string
+}
+
+// active reports whether the silence is active at the given time instant
+func (s *Silence) active(now time.Time) bool {
+	return !now.Before(s.Start) && now.Before(s.End)
+}
+
+// matches reports whether the silence matches the rule
+func (s *Silence) matches(rule AlertRule) bool {
+	if ok, _ := path.Match(s.Match, rule.Name); ok {
+		return true
+	}
+	ok, _ := path.Match(s.Match, rule.Metric)
+	return ok
+}
+
+// AddSilence silences the rules matched by s until s.End. Silences are
+// dropped once they end, so they can't be reused.
+//
+// The returned function removes the silence, e.g. when maintenance ends
+// early. It fails with path.ErrBadPattern if the pattern is malformed, and
+// with ErrInvalidConfig if the silence ends before it starts.
+func (a *Alerter) AddSilence(s Silence) (remove func(), err error) {
+	if _, err := path.Match(s.Match, ""); err != nil {
+		return nil, err
+	}
+	if !s.End.After(s.Start) {
+		return nil, fmt.Errorf("%w: silence %q ends before it starts", ErrInvalidConfig, s.Match)
+	}
+
+	a.mu.Lock()
+	a.silences = append(a.silences, &s)
+	a.mu.Unlock()
+
+	return func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+
+		for i, other := range a.silences {
+			if other == &s {
+				a.silences = append(a.silences[:i], a.silences[i+1:]...)
+				break
+			}
+		}
+	}, nil
+}
+
+// Silences returns the silences that haven't ended by the latest
+// evaluation, including the ones that haven't started yet
+func (a *Alerter) Silences() []Silence {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	silences := make([]Silence, len(a.silences))
+	for i, s := range a.silences {
+		silences[i] = *s
+	}
+	return silences
+}
+
+// silenced reports whether an active silence matches the rule.
+// It must be called with mu held.
+func (a *Alerter) silenced(rule AlertRule, now time.Time) bool {
+	for _, s := range a.silences {
+		if s.active(now) && s.matches(rule) {
+			return true
+		}
+	}
+	return false
+}
+
+// pruneSilences drops the silences that ended.
+// It must be called with mu held.
+func (a *Alerter) pruneSilences(now time.Time) {
+	kept := a.silences[:0]
+	for _, s := range a.silences {
+		if now.Before(s.End) {
+			kept = append(kept, s)
+		}
+	}
+	clear(a.silences[len(kept):])
+	a.silences = kept
+}
@@ -0,0 +1,128 @@
+package hops_test
+
+import (
+	"errors"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestAlerterSilence(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+
+	tests := map[string]struct {
+		// Minutes at which an event is observed
+		events []int
+
+		wantSent []hops.AlertState
+	}{
+		"fires_during_silence": {
+			events: []int{0, 1, 2, 3},
+			// Notified once the silence ends
+			wantSent: []hops.AlertState{hops.AlertFiring},
+		},
+		"resolves_during_silence": {
+			events:   []int{0},
+			wantSent: nil,
+		},
+		"resolves_after_silence": {
+			events:   []int{0, 1, 2},
+			wantSent: []hops.AlertState{hops.AlertFiring, hops.AlertResolved},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := hops.NewManualCounter(1, time.Minute, start)
+			r := hops.NewRegistry()
+			r.MustRegister("db.errors", c)
+
+			var sent []hops.AlertState
+			a := hops.NewAlerter(r, hops.NotifierFunc(func(alert hops.Alert) { sent = append(sent, alert.State) }))
+			a.AddRule(hops.AlertRule{Name: "db_errors", Metric: "db.errors", Comparator: hops.Above})
+			if _, err := a.AddSilence(hops.Silence{Match: "db.*", End: at(2)}); err != nil {
+				t.Fatal(err)
+			}
+
+			for minute := 0; minute <= 3; minute++ {
+				c.Tick(at(minute))
+				for _, m := range tt.events {
+					if m == minute {
+						c.Observe()
+					}
+				}
+				a.Evaluate(at(minute))
+
+				firing := a.Alerts()
+				if minute < 2 && len(firing) == 1 && !firing[0].Silenced {
+					t.Errorf("minute %d: expected the alert to be silenced", minute)
+				}
+			}
+
+			if len(sent) != len(tt.wantSent) {
+				t.Fatalf("expected: %v, got: %v", tt.wantSent, sent)
+			}
+			for i := range sent {
+				if sent[i] != tt.wantSent[i] {
+					t.Errorf("expected: %v, got: %v", tt.wantSent, sent)
+				}
+			}
+			if got := a.Silences(); len(got) != 0 {
+				t.Errorf("expected the silence to be dropped, got: %v", got)
+			}
+		})
+	}
+}
+
+func TestAlerterRemoveSilence(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	c := hops.NewManualCounter(1, time.Minute, start)
+	r := hops.NewRegistry()
+	r.MustRegister("db.errors", c)
+
+	sent := 0
+	a := hops.NewAlerter(r, hops.NotifierFunc(func(hops.Alert) { sent++ }))
+	a.AddRule(hops.AlertRule{Name: "db_errors", Metric: "db.errors", Comparator: hops.Above})
+	remove, err := a.AddSilence(hops.Silence{Match: "db_errors", End: start.Add(time.Hour), Comment: "failover"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Observe()
+	a.Evaluate(start)
+	if sent != 0 {
+		t.Errorf("expected no notifications while silenced, got: %d", sent)
+	}
+
+	remove()
+	a.Evaluate(start)
+	if sent != 1 {
+		t.Errorf("expected the alert to be notified once the silence is removed, got: %d", sent)
+	}
+}
+
+func TestAlerterAddSilenceErrors(t *testing.T) {
+	now := time.Now()
+
+	tests := map[string]struct {
+		silence hops.Silence
+		wantErr error
+	}{
+		"bad_pattern":  {hops.Silence{Match: "[", End: now}, path.ErrBadPattern},
+		"ends_early":   {hops.Silence{Match: "*", Start: now, End: now.Add(-time.Minute)}, hops.ErrInvalidConfig},
+		"no_end":       {hops.Silence{Match: "*"}, hops.ErrInvalidConfig},
+		"valid_window": {hops.Silence{Match: "*", Start: now, End: now.Add(time.Minute)}, nil},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			a := hops.NewAlerter(hops.NewRegistry())
+			if _, err := a.AddSilence(tt.silence); !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected: %v, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
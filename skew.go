@@ -0,0 +1,125 @@
+package hops
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// SkewPolicy decides how a counter handles the wall clock jumping, e.g.
+// when it's stepped by NTP or changed by hand
+type SkewPolicy int
+
+// Policies for SkewOptions
+const (
+	// SkewFollow keeps following the wall clock. Events observed after the
+	// clock moved back are counted in the current time unit, and a jump
+	// forward hops the window over the skipped time units.
+	SkewFollow SkewPolicy = iota
+
+	// SkewClamp ignores the jumps, and keeps counting time as if the wall
+	// clock never moved
+	SkewClamp
+
+	// SkewReset discards the events within the window and starts over
+	// from the new wall clock time, since they can't be placed reliably
+	// in time anymore
+	SkewReset
+)
+
+// Skew describes a jump of the wall clock
+type Skew struct {
+	// Wall clock time after the jump
+	At time.Time
+
+	// How far the wall clock jumped, negative if it moved back
+	Jump time.Duration
+}
+
+// SkewOptions configures how a counter detects and handles jumps of the
+// wall clock
+type SkewOptions struct {
+	Policy SkewPolicy
+
+	// Smallest jump that is considered skew. Defaults to 1 second.
+	Tolerance time.Duration
+
+	// Called on every detected jump, if set. It must not block.
+	OnSkew func(Skew)
+}
+
+// skewState holds the clock skew handling of a counter
+type skewState struct {
+	opts atomic.Pointer[SkewOptions]
+
+	// Total size of the detected jumps, in nanoseconds
+	drift atomic.Int64
+
+	// Number of detected jumps
+	count atomic.Uint64
+}
+
+// SetSkewOptions enables the detection of jumps of the wall clock, which
+// are handled according to o.Policy. Jumps are detected by comparing the
+// wall clock against the monotonic clock, so the time between events
+// doesn't matter.
+//
+// Detection is off by default. It has no effect on manual counters, whose
+// time only moves forward through Tick.
+func (c *Counter) SetSkewOptions(o SkewOptions) {
+	if o.Tolerance <= 0 {
+		o.Tolerance = time.Second
+	}
+	c.skew.opts.Store(&o)
+}
+
+// Skews returns the number of jumps of the wall clock detected since
+// SetSkewOptions was called
+func (c *Counter) Skews() int {
+	return int(c.skew.count.Load())
+}
+
+// skewTime handles a jump of the wall clock, if any, given the time instant
+// now and how far the wall clock moved away from the monotonic clock since
+// the counter was created. It returns the time instant the counter must
+// use.
+func (c *Counter) skewTime(now time.Time, drift time.Duration) time.Time {
+	o := c.skew.opts.Load()
+	if o == nil {
+		return now
+	}
+
+	last := c.skew.drift.Load()
+	jump := drift - time.Duration(last)
+	if jump.Abs() >= o.Tolerance && c.skew.drift.CompareAndSwap(last, int64(drift)) {
+		c.skew.count.Add(1)
+		if o.Policy == SkewReset {
+			c.resetWindow(now)
+		}
+		if o.OnSkew != nil {
+			o.OnSkew(Skew{At: now, Jump: jump})
+		}
+	}
+
+	if o.Policy == SkewClamp {
+		return now.Add(-time.Duration(c.skew.drift.Load()))
+	}
+	return now
+}
+
+// wallDrift returns how far the wall clock moved away from the monotonic
+// clock between since and now
+func wallDrift(since, now time.Time) time.Duration {
+	return now.Round(0).Sub(since.Round(0)) - now.Sub(since)
+}
+
+// resetWindow discards all events and moves the window such that its end
+// is on the given time instant
+func (c *Counter) resetWindow(now time.Time) {
+	c.packed.Store(0)
+
+	c.mu.Lock()
+	clear(c.prevCounts)
+	atomic.StoreUint32(&c.crtCount, 0)
+	c.windowStart = now.Truncate(c.Unit).Add(c.Unit - c.WindowSize)
+	c.mu.Unlock()
+}
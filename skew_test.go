@@ -0,0 +1,70 @@
+package hops
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSkewPolicies(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 30, 0, time.UTC)
+	jump := -time.Hour
+
+	tests := map[string]struct {
+		policy    SkewPolicy
+		wantTime  time.Time
+		wantValue int
+	}{
+		"follow": {SkewFollow, start.Add(jump), 3},
+		"clamp":  {SkewClamp, start, 3},
+		"reset":  {SkewReset, start.Add(jump), 0},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := newCounter(5, time.Minute, start)
+			for i := 0; i < 3; i++ {
+				c.Observe()
+			}
+
+			var skews []Skew
+			c.SetSkewOptions(SkewOptions{Policy: tt.policy, OnSkew: func(s Skew) { skews = append(skews, s) }})
+
+			// Small drift is tolerated
+			if got := c.skewTime(start, 10*time.Millisecond); !got.Equal(start) {
+				t.Errorf("expected: %v, got: %v", start, got)
+			}
+
+			// The wall clock moves back while the monotonic clock doesn't
+			if got := c.skewTime(start.Add(jump), jump); !got.Equal(tt.wantTime) {
+				t.Errorf("expected: %v, got: %v", tt.wantTime, got)
+			}
+			// Detected only once
+			c.skewTime(start.Add(jump), jump)
+
+			if c.Skews() != 1 || len(skews) != 1 || skews[0].Jump != jump {
+				t.Errorf("expected a single jump of %v, got: %v", jump, skews)
+			}
+
+			c.mu.RLock()
+			got := int(c.crtCount)
+			for _, n := range c.prevCounts {
+				got += int(n)
+			}
+			c.mu.RUnlock()
+			if got != tt.wantValue {
+				t.Errorf("expected: %d events, got: %d", tt.wantValue, got)
+			}
+		})
+	}
+}
+
+func TestSkewDisabled(t *testing.T) {
+	c := NewCounter(5, time.Minute)
+	now := time.Now()
+	if got := c.skewTime(now, time.Hour); !got.Equal(now) {
+		t.Errorf("expected: %v, got: %v", now, got)
+	}
+	if c.Skews() != 0 {
+		t.Errorf("expected no detection by default")
+	}
+}
@@ -0,0 +1,123 @@
+package hops
+
+import (
+	"sync"
+	"time"
+)
+
+// SlidingLog is a rate limiter that remembers the exact time of every
+// allowed event, and allows a new one only if fewer than limit events
+// happened within the last window.
+//
+// It's exact, unlike WindowLimiter which has a precision of one time unit,
+// but takes memory proportional to the limit. It's meant for strict, low
+// limits such as 5 password attempts per 15 minutes.
+//
+// It's safe to use the limiter concurrently.
+type SlidingLog struct {
+	// Guards all the fields below
+	mu sync.Mutex
+
+	// Times of the allowed events within the window, oldest first
+	log []time.Time
+
+	limit  int
+	window time.Duration
+
+	// Set for limiters that are advanced explicitly through Tick
+	manual bool
+
+	// Latest time instant passed to Tick
+	tickTime time.Time
+}
+
+// NewSlidingLog creates a limiter that allows at most limit events within
+// any period of the given duration
+func NewSlidingLog(limit int, window time.Duration) *SlidingLog {
+	return &SlidingLog{
+		log:    make([]time.Time, 0, limit),
+		limit:  limit,
+		window: window,
+	}
+}
+
+// NewManualSlidingLog creates a sliding log limiter that doesn't follow the
+// wall clock. Time only moves forward when the application calls Tick.
+func NewManualSlidingLog(limit int, window time.Duration, now time.Time) *SlidingLog {
+	l := NewSlidingLog(limit, window)
+	l.manual = true
+	l.tickTime = now
+	return l
+}
+
+// Allow reports whether an event may happen now, and records it if so
+func (l *SlidingLog) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.prune()
+	if len(l.log) >= l.limit {
+		return false
+	}
+	l.log = append(l.log, now)
+	return true
+}
+
+// Status returns the state of the limit at the current moment in time.
+// Reset is the time until the oldest event leaves the window.
+func (l *SlidingLog) Status() LimitStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.prune()
+	status := LimitStatus{Limit: l.limit, Remaining: l.limit - len(l.log)}
+	if status.Remaining < 0 {
+		status.Remaining = 0
+	}
+	if len(l.log) > 0 {
+		status.Reset = l.log[0].Add(l.window).Sub(now)
+	}
+	return status
+}
+
+// SetLimit changes the number of events allowed within the window. The
+// change takes effect immediately, and keeps the events already in the
+// window.
+func (l *SlidingLog) SetLimit(limit int) {
+	l.mu.Lock()
+	l.limit = limit
+	l.mu.Unlock()
+}
+
+// Tick advances a manual limiter to the given time instant. Time instants
+// older than the latest one passed to Tick are ignored.
+//
+// Tick has no effect on limiters that aren't created by NewManualSlidingLog.
+func (l *SlidingLog) Tick(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.manual && now.After(l.tickTime) {
+		l.tickTime = now
+	}
+}
+
+// prune removes the events that fell out of the window, and returns the
+// current time instant. Must be called with mu held.
+func (l *SlidingLog) prune() time.Time {
+	now := l.tickTime
+	if !l.manual {
+		now = time.Now()
+	}
+
+	expired := 0
+	for expired < len(l.log) && !l.log[expired].After(now.Add(-l.window)) {
+		expired++
+	}
+	if expired > 0 {
+		// Shift in place to keep reusing the same backing array
+		n := copy(l.log, l.log[expired:])
+		l.log = l.log[:n]
+	}
+	return now
+}
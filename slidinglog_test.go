@@ -0,0 +1,43 @@
+package hops_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestSlidingLog(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 0, 0, 0, time.UTC)
+	l := hops.NewManualSlidingLog(3, 15*time.Minute, start)
+
+	// Three attempts spread over 10 minutes
+	for i := 0; i < 3; i++ {
+		l.Tick(start.Add(time.Duration(i*5) * time.Minute))
+		if !l.Allow() {
+			t.Fatalf("expected attempt %d to be allowed", i)
+		}
+	}
+	if l.Allow() {
+		t.Errorf("expected the fourth attempt to be denied")
+	}
+
+	status := l.Status()
+	if status.Remaining != 0 || status.Reset != 5*time.Minute {
+		t.Errorf("expected remaining: 0, reset: 5m, got: %+v", status)
+	}
+
+	// Just before the first attempt expires
+	l.Tick(start.Add(15*time.Minute - time.Nanosecond))
+	if l.Allow() {
+		t.Errorf("expected attempts to be denied until the first one expires")
+	}
+
+	l.Tick(start.Add(15 * time.Minute))
+	if !l.Allow() {
+		t.Errorf("expected an attempt to be allowed once the first one expired")
+	}
+	if l.Allow() {
+		t.Errorf("expected only one attempt to be freed up")
+	}
+}
@@ -0,0 +1,104 @@
+package hops
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// Filter smooths a series of window totals
+type Filter int
+
+// Filters supported by Smoothed
+const (
+	// FilterMean averages the window totals
+	FilterMean Filter = iota
+
+	// FilterMedian takes the median of the window totals, which ignores
+	// isolated spikes altogether
+	FilterMedian
+)
+
+// Smoothed reports the window total of a counter smoothed over its last
+// hops, so noisy signals, e.g. driving autoscaling decisions, don't flap.
+//
+// It's safe to use it concurrently.
+type Smoothed struct {
+	c      *Counter
+	filter Filter
+
+	// Guards totals and next
+	mu sync.Mutex
+
+	// Ring of the window totals at the last hops
+	totals []int
+
+	// Index of the next total to overwrite, once the ring is full
+	next int
+
+	hops int
+}
+
+// NewSmoothed creates a smoothed view of c, which applies filter to the
+// window totals of the last hops. It's updated by Run.
+func NewSmoothed(c *Counter, filter Filter, hops int) *Smoothed {
+	return &Smoothed{c: c, filter: filter, totals: make([]int, 0, hops), hops: hops}
+}
+
+// Run records the window total every time the window of the counter hops,
+// until ctx is done
+func (s *Smoothed) Run(ctx context.Context) {
+	for snapshot := range s.c.Hops(ctx) {
+		s.record(snapshot.Total)
+	}
+}
+
+// Value returns the smoothed window total, or the raw one if the window
+// didn't hop yet
+func (s *Smoothed) Value() float64 {
+	s.mu.Lock()
+	totals := slices.Clone(s.totals)
+	s.mu.Unlock()
+
+	if len(totals) == 0 {
+		return float64(s.c.Value())
+	}
+
+	switch s.filter {
+	case FilterMedian:
+		slices.Sort(totals)
+		mid := len(totals) / 2
+		if len(totals)%2 == 1 {
+			return float64(totals[mid])
+		}
+		return float64(totals[mid-1]+totals[mid]) / 2
+	default:
+		sum := 0
+		for _, t := range totals {
+			sum += t
+		}
+		return float64(sum) / float64(len(totals))
+	}
+}
+
+// Raw returns the window total of the counter, without smoothing
+func (s *Smoothed) Raw() int {
+	return s.c.Value()
+}
+
+// record adds a window total to the ring, dropping the oldest one if the
+// ring is full
+func (s *Smoothed) record(total int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.hops <= 0 {
+		return
+	}
+	if len(s.totals) < s.hops {
+		s.totals = append(s.totals, total)
+		return
+	}
+	s.totals[s.next] = total
+	s.next = (s.next + 1) % s.hops
+}
@@ -0,0 +1,49 @@
+package hops
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSmoothed(t *testing.T) {
+	tests := map[string]struct {
+		filter Filter
+		hops   int
+		totals []int
+		want   float64
+	}{
+		"mean":             {FilterMean, 3, []int{10, 20, 60}, 30},
+		"mean_last_hops":   {FilterMean, 3, []int{1000, 10, 20, 60}, 30},
+		"mean_partial":     {FilterMean, 3, []int{10}, 10},
+		"median_odd":       {FilterMedian, 3, []int{10, 500, 12}, 12},
+		"median_even":      {FilterMedian, 4, []int{10, 500, 12, 11, 13}, 12.5},
+		"median_last_hops": {FilterMedian, 3, []int{7, 7, 7, 1, 2, 3}, 2},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := NewSmoothed(NewCounter(5, time.Minute), tt.filter, tt.hops)
+			for _, total := range tt.totals {
+				s.record(total)
+			}
+
+			if got := s.Value(); got != tt.want {
+				t.Errorf("expected: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSmoothedBeforeFirstHop(t *testing.T) {
+	c := NewCounter(5, time.Minute)
+	c.Observe()
+	c.Observe()
+
+	s := NewSmoothed(c, FilterMean, 3)
+	if got := s.Value(); got != 2 {
+		t.Errorf("expected the raw value: 2, got: %v", got)
+	}
+	if got := s.Raw(); got != 2 {
+		t.Errorf("expected: 2, got: %v", got)
+	}
+}
@@ -0,0 +1,164 @@
+package hops
+
+import (
+	"context"
+	"iter"
+	"sync/atomic"
+	"time"
+)
+
+// Snapshot is a point-in-time copy of a counter's window.
+type Snapshot struct {
+	// Start of the window
+	Start time.Time
+
+	// Duration of a time unit
+	Unit time.Duration
+
+	// Number of events that happened in each time unit of the window,
+	// ordered from the oldest time unit to the current one.
+	Counts []uint32
+
+	// Number of events within the window
+	Total int
+
+	// Exemplar of each time unit of the window, ordered like Counts, or
+	// nil if the counter has none. Time units without exemplars have the
+	// zero Exemplar.
+	Exemplars []Exemplar
+}
+
+// BucketSample is the number of events that happened in one time unit
+type BucketSample struct {
+	// Start of the time unit
+	Start time.Time
+
+	// Number of events that happened in the time unit
+	Count uint32
+}
+
+// Snapshot returns a copy of the counter's window at the current moment in time
+func (c *Counter) Snapshot() Snapshot {
+	var s Snapshot
+	c.SnapshotInto(&s)
+	return s
+}
+
+// SnapshotInto copies the counter's window at the current moment in time
+// into s, reusing the memory of s.Counts when it's large enough.
+//
+// It's meant for exporters that scrape many counters frequently and want
+// to avoid generating garbage on every scrape.
+func (c *Counter) SnapshotInto(s *Snapshot) {
+	if c.isPacked {
+		now := c.now()
+		count := c.packedCount(now)
+		s.Start = now.Truncate(c.Unit)
+		s.Unit = c.Unit
+		s.Counts = append(s.Counts[:0], count)
+		s.Total = int(count)
+
+		c.mu.RLock()
+		c.appendExemplars(s)
+		c.mu.RUnlock()
+		return
+	}
+
+	c.refreshWindow()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	s.Start = c.windowStart
+	s.Unit = c.Unit
+	s.Counts = append(s.Counts[:0], c.prevCounts...)
+	s.Counts = append(s.Counts, atomic.LoadUint32(&c.crtCount))
+
+	s.Total = 0
+	for _, n := range s.Counts {
+		s.Total += int(n)
+	}
+	c.appendExemplars(s)
+}
+
+// AppendSnapshot appends a sample for each time unit of the window to dst,
+// ordered from the oldest time unit to the current one, and returns the
+// extended slice. It doesn't allocate if dst has enough capacity.
+func (c *Counter) AppendSnapshot(dst []BucketSample) []BucketSample {
+	if c.isPacked {
+		now := c.now()
+		return append(dst, BucketSample{Start: now.Truncate(c.Unit), Count: c.packedCount(now)})
+	}
+
+	c.refreshWindow()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	start := c.windowStart
+	for _, n := range c.prevCounts {
+		dst = append(dst, BucketSample{Start: start, Count: n})
+		start = start.Add(c.Unit)
+	}
+	return append(dst, BucketSample{Start: start, Count: atomic.LoadUint32(&c.crtCount)})
+}
+
+// Hops returns a sequence that yields a snapshot of the counter each time
+// the window hops forward by one time unit. The sequence ends when ctx is
+// done or when the caller stops ranging over it.
+//
+// For manual counters, a snapshot is yielded every time Tick moves the
+// window, no matter how many time units it hopped over.
+//
+// For example, this prints the number of events from the last 5 minutes,
+// once every minute:
+//
+//	c := hops.NewCounter(5, time.Minute)
+//	for s := range c.Hops(ctx) {
+//		fmt.Println(s.Total)
+//	}
+func (c *Counter) Hops(ctx context.Context) iter.Seq[Snapshot] {
+	if c.manual {
+		return c.manualHops(ctx)
+	}
+
+	return func(yield func(Snapshot) bool) {
+		for {
+			// Wait until the beginning of the next time unit
+			next := time.Now().Truncate(c.Unit).Add(c.Unit)
+			timer := time.NewTimer(time.Until(next))
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			if !yield(c.Snapshot()) {
+				return
+			}
+		}
+	}
+}
+
+// manualHops is the Hops implementation for manual counters
+func (c *Counter) manualHops(ctx context.Context) iter.Seq[Snapshot] {
+	return func(yield func(Snapshot) bool) {
+		for {
+			c.mu.RLock()
+			ticked := c.ticked
+			c.mu.RUnlock()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticked:
+			}
+
+			if !yield(c.Snapshot()) {
+				return
+			}
+		}
+	}
+}
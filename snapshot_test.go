@@ -0,0 +1,175 @@
+package hops
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	c := NewCounter(5, time.Hour)
+	c.prevCounts = []uint32{1, 2, 3, 4}
+	c.crtCount = 5
+
+	s := c.Snapshot()
+
+	if want := []uint32{1, 2, 3, 4, 5}; !reflect.DeepEqual(s.Counts, want) {
+		t.Errorf("expected counts: %v, got: %v", want, s.Counts)
+	}
+	if s.Total != 15 {
+		t.Errorf("expected total: 15, got: %d", s.Total)
+	}
+	if !s.Start.Equal(c.windowStart) {
+		t.Errorf("expected start: %v, got: %v", c.windowStart, s.Start)
+	}
+
+	// The snapshot must not share memory with the counter
+	s.Counts[0] = 99
+	if c.prevCounts[0] != 1 {
+		t.Errorf("Snapshot shares memory with the counter")
+	}
+}
+
+func TestHops(t *testing.T) {
+	c := NewCounter(3, 20*time.Millisecond)
+	c.Observe()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	var snapshots []Snapshot
+	for s := range c.Hops(ctx) {
+		snapshots = append(snapshots, s)
+		if len(snapshots) == 3 {
+			break
+		}
+	}
+
+	if len(snapshots) != 3 {
+		t.Fatalf("expected 3 snapshots, got: %d", len(snapshots))
+	}
+	for i := 1; i < len(snapshots); i++ {
+		if !snapshots[i].Start.After(snapshots[i-1].Start) {
+			t.Errorf("window did not hop forward between snapshots %d and %d", i-1, i)
+		}
+	}
+}
+
+func TestHopsStopsWhenContextIsDone(t *testing.T) {
+	c := NewCounter(3, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for range c.Hops(ctx) {
+		t.Fatal("expected no snapshots after the context is done")
+	}
+}
+
+func TestManualHops(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+	c := NewManualCounter(3, time.Second, start)
+	initialStart := c.Snapshot().Start
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// Keep ticking until the iterator picks up a hop
+	go func() {
+		now := start
+		for ctx.Err() == nil {
+			now = now.Add(time.Second)
+			c.Tick(now)
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	for s := range c.Hops(ctx) {
+		if !s.Start.After(initialStart) {
+			t.Errorf("expected the window to start after %v, got: %v", initialStart, s.Start)
+		}
+		return
+	}
+	t.Error("expected a snapshot after the window moved")
+}
+
+func TestAppendSnapshot(t *testing.T) {
+	c := NewCounter(3, time.Hour)
+	c.prevCounts = []uint32{1, 2}
+	c.crtCount = 3
+
+	dst := c.AppendSnapshot([]BucketSample{{Count: 99}})
+
+	want := []BucketSample{
+		{Count: 99},
+		{Start: c.windowStart, Count: 1},
+		{Start: c.windowStart.Add(time.Hour), Count: 2},
+		{Start: c.windowStart.Add(2 * time.Hour), Count: 3},
+	}
+	if !reflect.DeepEqual(dst, want) {
+		t.Errorf("expected: %v, got: %v", want, dst)
+	}
+}
+
+func TestSnapshotIntoReusesMemory(t *testing.T) {
+	c := NewCounter(3, time.Hour)
+	c.crtCount = 7
+
+	s := Snapshot{Counts: make([]uint32, 0, 3), Total: 100}
+	counts := s.Counts[:1]
+	c.SnapshotInto(&s)
+
+	if &counts[0] != &s.Counts[0] {
+		t.Errorf("SnapshotInto did not reuse the counts slice")
+	}
+	if s.Total != 7 {
+		t.Errorf("expected total: 7, got: %d", s.Total)
+	}
+}
+
+func TestHotPathsDoNotAllocate(t *testing.T) {
+	c := NewCounter(60, time.Second)
+	s := Snapshot{Counts: make([]uint32, 0, 60)}
+	samples := make([]BucketSample, 0, 60)
+
+	tests := map[string]func(){
+		"value":           func() { c.Value() },
+		"snapshot_into":   func() { c.SnapshotInto(&s) },
+		"append_snapshot": func() { samples = c.AppendSnapshot(samples[:0]) },
+	}
+
+	for name, fn := range tests {
+		t.Run(name, func(t *testing.T) {
+			if allocs := testing.AllocsPerRun(100, fn); allocs != 0 {
+				t.Errorf("expected no allocations, got: %v", allocs)
+			}
+		})
+	}
+}
+
+func BenchmarkValue(b *testing.B) {
+	c := NewCounter(60, time.Second)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.Value()
+	}
+}
+
+func BenchmarkSnapshotInto(b *testing.B) {
+	c := NewCounter(60, time.Second)
+	var s Snapshot
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.SnapshotInto(&s)
+	}
+}
+
+func BenchmarkAppendSnapshot(b *testing.B) {
+	c := NewCounter(60, time.Second)
+	var samples []BucketSample
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		samples = c.AppendSnapshot(samples[:0])
+	}
+}
@@ -0,0 +1,128 @@
+package hops
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"time"
+)
+
+// minInterval is the shortest pace at which the SSE handlers stream
+// values, so a client can't make them flood the connection
+const minInterval = 100 * time.Millisecond
+
+// sseEvent is the payload of an event sent by the SSE handlers
+type sseEvent struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// NewSSEHandler returns an HTTP handler that streams the value of c as
+// Server-Sent Events, so web pages can show it live without polling.
+//
+// The current value is sent as soon as a client connects, and then again
+// every time the window hops. Clients can ask for a different pace with the
+// "interval" query parameter, which accepts durations such as "500ms" or
+// "10s", down to 100ms; shorter intervals are rejected with 400 Bad Request.
+// Each event carries a JSON object:
+//
+//	data: {"time":"2021-03-14T15:09:26Z","value":42}
+func NewSSEHandler(c *Counter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		event := func() sseEvent {
+			return sseEvent{Time: c.now(), Value: float64(c.Value())}
+		}
+		serveSSE(w, r, event, c.Hops(r.Context()))
+	})
+}
+
+// NewGaugeSSEHandler is like NewSSEHandler, but streams the most recent
+// sample of g, once per time unit of g unless the "interval" query
+// parameter asks for a different pace
+func NewGaugeSSEHandler(g *Gauge) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		event := func() sseEvent {
+			g.mu.Lock()
+			now := g.now()
+			g.mu.Unlock()
+			return sseEvent{Time: now, Value: g.Stats().Last}
+		}
+		serveSSE(w, r, event, every(r, g.slots.Unit()))
+	})
+}
+
+// serveSSE sends an event as soon as a client connects, and then again
+// every time hops yields, or once every interval if the client asks for
+// one, until the client goes away
+func serveSSE(w http.ResponseWriter, r *http.Request, event func() sseEvent, hops iter.Seq[Snapshot]) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	if s := r.URL.Query().Get("interval"); s != "" {
+		d, err := parseInterval(s)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		hops = every(r, d)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	send := func() bool {
+		data, _ := json.Marshal(event())
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+
+	if !send() {
+		return
+	}
+	for range hops {
+		if !send() {
+			return
+		}
+	}
+}
+
+// parseInterval parses the "interval" query parameter of the SSE handlers
+func parseInterval(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, errors.New("invalid interval")
+	}
+	if d < minInterval {
+		return 0, fmt.Errorf("interval must be at least %v", minInterval)
+	}
+	return d, nil
+}
+
+// every returns a sequence that yields once every interval, until the
+// request is done
+func every(r *http.Request, interval time.Duration) iter.Seq[Snapshot] {
+	return func(yield func(Snapshot) bool) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-ticker.C:
+				if !yield(Snapshot{}) {
+					return
+				}
+			}
+		}
+	}
+}
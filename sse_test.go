@@ -0,0 +1,95 @@
+package hops_test
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestSSEHandler(t *testing.T) {
+	c := hops.NewCounter(5, time.Hour)
+	c.Observe()
+	c.Observe()
+
+	srv := httptest.NewServer(hops.NewSSEHandler(c))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "?interval=100ms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("expected content type: text/event-stream, got: %s", ct)
+	}
+
+	// Read a couple of events
+	events := 0
+	scanner := bufio.NewScanner(resp.Body)
+	for events < 2 && scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "data: ") || !strings.Contains(line, `"value":2`) {
+			t.Errorf("unexpected event: %q", line)
+		}
+		events++
+	}
+	if events != 2 {
+		t.Errorf("expected 2 events, got: %d", events)
+	}
+}
+
+func TestSSEHandlerInvalidInterval(t *testing.T) {
+	tests := map[string]string{
+		"malformed": "soon",
+		"negative":  "-1s",
+		"too_short": "10ms",
+	}
+
+	c := hops.NewCounter(5, time.Hour)
+	for name, interval := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/?interval="+interval, nil)
+			hops.NewSSEHandler(c).ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("expected status: %d, got: %d", http.StatusBadRequest, rec.Code)
+			}
+		})
+	}
+}
+
+func TestGaugeSSEHandler(t *testing.T) {
+	r := hops.NewRegistry()
+	stop, err := r.Poll("queue.size", time.Hour, func() float64 { return 2.5 })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	srv := httptest.NewServer(hops.NewGaugeSSEHandler(r.Gauge("queue.size")))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(line, `"value":2.5`) {
+		t.Errorf("unexpected event: %q", line)
+	}
+}
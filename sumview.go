@@ -0,0 +1,67 @@
+package hops
+
+import "fmt"
+
+// SumView is a read-only view over several counters that reports their
+// aggregate, e.g. to roll per-worker counters up into a per-process number.
+//
+// It's safe to use the view concurrently.
+type SumView struct {
+	counters []*Counter
+}
+
+// NewSumView creates a view that sums the given counters.
+// It fails with ErrIncompatibleWindow if the counters don't share the same
+// window size and time unit.
+func NewSumView(counters ...*Counter) (*SumView, error) {
+	for i := 1; i < len(counters); i++ {
+		c := counters[i]
+		if c.WindowSize != counters[0].WindowSize || c.Unit != counters[0].Unit {
+			return nil, fmt.Errorf("%w: %v/%v and %v/%v differ", ErrIncompatibleWindow,
+				counters[0].WindowSize, counters[0].Unit, c.WindowSize, c.Unit)
+		}
+	}
+
+	return &SumView{counters: append([]*Counter(nil), counters...)}, nil
+}
+
+// Value returns the number of events within the window of all counters
+func (v *SumView) Value() int {
+	sum := 0
+	for _, c := range v.counters {
+		sum += c.Value()
+	}
+	return sum
+}
+
+// Snapshot returns the sum of the counters' windows at the current moment
+// in time, matching time units by their start
+func (v *SumView) Snapshot() Snapshot {
+	if len(v.counters) == 0 {
+		return Snapshot{}
+	}
+
+	snapshots := make([]Snapshot, len(v.counters))
+	for i, c := range v.counters {
+		snapshots[i] = c.Snapshot()
+	}
+
+	// Windows might hop while the snapshots are taken, so line them up
+	// against the most recent one
+	sum := Snapshot{Start: snapshots[0].Start, Unit: snapshots[0].Unit}
+	for _, s := range snapshots[1:] {
+		if s.Start.After(sum.Start) {
+			sum.Start = s.Start
+		}
+	}
+	sum.Counts = make([]uint32, len(snapshots[0].Counts))
+
+	for _, s := range snapshots {
+		offset := int(sum.Start.Sub(s.Start) / s.Unit)
+		for i := offset; i < len(s.Counts); i++ {
+			sum.Counts[i-offset] += s.Counts[i]
+			sum.Total += int(s.Counts[i])
+		}
+	}
+	return sum
+}
@@ -0,0 +1,41 @@
+package hops
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSumView(t *testing.T) {
+	a := NewCounter(3, time.Hour)
+	a.prevCounts = []uint32{1, 2}
+	a.crtCount = 3
+	b := NewCounter(3, time.Hour)
+	b.prevCounts = []uint32{10, 20}
+	b.crtCount = 30
+
+	v, err := NewSumView(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := v.Value(); got != 66 {
+		t.Errorf("expected value: 66, got: %d", got)
+	}
+
+	s := v.Snapshot()
+	if want := []uint32{11, 22, 33}; !reflect.DeepEqual(s.Counts, want) {
+		t.Errorf("expected counts: %v, got: %v", want, s.Counts)
+	}
+	if s.Total != 66 {
+		t.Errorf("expected total: 66, got: %d", s.Total)
+	}
+}
+
+func TestSumViewIncompatibleWindows(t *testing.T) {
+	_, err := NewSumView(NewCounter(3, time.Hour), NewCounter(5, time.Hour))
+	if !errors.Is(err, ErrIncompatibleWindow) {
+		t.Errorf("expected: %v, got: %v", ErrIncompatibleWindow, err)
+	}
+}
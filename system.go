@@ -0,0 +1,137 @@
+package hops
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Clock ticks per second in /proc/self/stat, which is 100 on virtually
+// every Linux system
+const procClockTicks = 100
+
+// SystemCollector periodically samples resource usage of the process into
+// hopping windows, giving small services a short-horizon view of their
+// resources without an external agent.
+//
+// It reads the /proc file system, so it only collects on Linux. Elsewhere,
+// its gauges stay empty.
+type SystemCollector struct {
+	// CPU cores used since the previous collection, e.g. 1.5 when the
+	// process kept one core and a half busy
+	CPU *Gauge
+
+	// Resident set size, in bytes
+	RSSBytes *Gauge
+
+	// Number of open file descriptors
+	OpenFDs *Gauge
+
+	// CPU time of the process at the previous collection
+	lastCPU     time.Duration
+	lastCPUTime time.Time
+}
+
+// NewSystemCollector creates a collector whose gauges have the given
+// window size and time unit
+func NewSystemCollector(windowSize int, timeUnit time.Duration) *SystemCollector {
+	return &SystemCollector{
+		CPU:      NewGauge(windowSize, timeUnit),
+		RSSBytes: NewGauge(windowSize, timeUnit),
+		OpenFDs:  NewGauge(windowSize, timeUnit),
+	}
+}
+
+// Register adds the gauges of the collector to r, under the names
+// process.cpu, process.rss.bytes and process.fds
+func (c *SystemCollector) Register(r *Registry) error {
+	if err := r.RegisterGauge("process.cpu", c.CPU); err != nil {
+		return err
+	}
+	if err := r.RegisterGauge("process.rss.bytes", c.RSSBytes); err != nil {
+		return err
+	}
+	return r.RegisterGauge("process.fds", c.OpenFDs)
+}
+
+// Run collects the metrics once every interval, until ctx is done
+func (c *SystemCollector) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		c.Collect()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Collect samples the resource usage of the process once. Metrics that
+// can't be read are skipped. CPU usage is sampled from the second
+// collection on, since it's measured between two collections.
+func (c *SystemCollector) Collect() {
+	now := time.Now()
+	if cpu, ok := readProcCPU(); ok {
+		if !c.lastCPUTime.IsZero() {
+			c.CPU.Set(float64(cpu-c.lastCPU) / float64(now.Sub(c.lastCPUTime)))
+		}
+		c.lastCPU, c.lastCPUTime = cpu, now
+	}
+
+	if rss, ok := readProcRSS(); ok {
+		c.RSSBytes.Set(float64(rss))
+	}
+
+	if entries, err := os.ReadDir("/proc/self/fd"); err == nil {
+		c.OpenFDs.Set(float64(len(entries)))
+	}
+}
+
+// readProcCPU returns the CPU time of the process, in user and system mode
+func readProcCPU() (time.Duration, bool) {
+	data, err := os.ReadFile("/proc/self/stat")
+	if err != nil {
+		return 0, false
+	}
+
+	// The command name may contain spaces, so skip past its closing
+	// parenthesis. utime and stime are the 14th and 15th fields.
+	i := strings.LastIndexByte(string(data), ')')
+	if i < 0 {
+		return 0, false
+	}
+	fields := strings.Fields(string(data[i+1:]))
+	if len(fields) < 13 {
+		return 0, false
+	}
+	utime, err1 := strconv.ParseInt(fields[11], 10, 64)
+	stime, err2 := strconv.ParseInt(fields[12], 10, 64)
+	if err1 != nil || err2 != nil {
+		return 0, false
+	}
+	return time.Duration(utime+stime) * time.Second / procClockTicks, true
+}
+
+// readProcRSS returns the resident set size of the process, in bytes
+func readProcRSS() (int64, bool) {
+	data, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		return 0, false
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return 0, false
+	}
+	pages, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return pages * int64(os.Getpagesize()), true
+}
@@ -0,0 +1,37 @@
+package hops_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestSystemCollector(t *testing.T) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("no /proc file system")
+	}
+
+	c := hops.NewSystemCollector(5, time.Minute)
+	r := hops.NewRegistry()
+	if err := c.Register(r); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Collect()
+	// Keep a core busy for a while
+	for deadline := time.Now().Add(50 * time.Millisecond); time.Now().Before(deadline); {
+	}
+	c.Collect()
+
+	if got := r.Gauge("process.cpu").Stats(); got.Count != 1 || got.Last <= 0 {
+		t.Errorf("expected a single positive CPU sample, got: %+v", got)
+	}
+	if got := r.Gauge("process.rss.bytes").Stats().Last; got <= 0 {
+		t.Errorf("expected a positive RSS, got: %v", got)
+	}
+	if got := r.Gauge("process.fds").Stats().Last; got < 3 {
+		t.Errorf("expected at least 3 open file descriptors, got: %v", got)
+	}
+}
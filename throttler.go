@@ -0,0 +1,75 @@
+package hops
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Throttler implements the adaptive client-side throttling from the Google
+// SRE book. It tracks how many requests a client made and how many of them
+// the backend accepted, and once the backend rejects many of them, it
+// starts rejecting requests locally, with probability
+//
+//	max(0, (requests - k*accepts) / (requests + 1))
+//
+// so a struggling backend isn't hammered with requests it would reject
+// anyway. Lower values of k throttle more aggressively; 2 is a good start.
+//
+// It's safe to use the throttler concurrently.
+type Throttler struct {
+	requests *Counter
+	accepts  *Counter
+	k        float64
+}
+
+// NewThrottler creates a throttler that tracks requests within the given
+// window, with multiplier k
+func NewThrottler(windowSize int, timeUnit time.Duration, k float64) *Throttler {
+	return &Throttler{
+		requests: NewCounter(windowSize, timeUnit),
+		accepts:  NewCounter(windowSize, timeUnit),
+		k:        k,
+	}
+}
+
+// NewManualThrottler creates a throttler that doesn't follow the wall
+// clock. It only moves forward when the application calls Tick.
+func NewManualThrottler(windowSize int, timeUnit time.Duration, k float64, now time.Time) *Throttler {
+	return &Throttler{
+		requests: NewManualCounter(windowSize, timeUnit, now),
+		accepts:  NewManualCounter(windowSize, timeUnit, now),
+		k:        k,
+	}
+}
+
+// Allow records a request and reports whether it should be sent to the
+// backend. Requests rejected locally are recorded as well, so the rejection
+// probability keeps growing while the backend is down.
+func (t *Throttler) Allow() bool {
+	p := t.RejectProbability()
+	t.requests.Observe()
+	return p == 0 || rand.Float64() >= p
+}
+
+// Accepted records that the backend accepted a request, e.g. it didn't
+// fail with an overload error
+func (t *Throttler) Accepted() {
+	t.accepts.Observe()
+}
+
+// RejectProbability returns the probability that the next request is
+// rejected locally
+func (t *Throttler) RejectProbability() float64 {
+	requests := float64(t.requests.Value())
+	accepts := float64(t.accepts.Value())
+	return max(0, (requests-t.k*accepts)/(requests+1))
+}
+
+// Tick advances a manual throttler to the given time instant.
+//
+// Tick has no effect on throttlers that aren't created by
+// NewManualThrottler.
+func (t *Throttler) Tick(now time.Time) {
+	t.requests.Tick(now)
+	t.accepts.Tick(now)
+}
@@ -0,0 +1,73 @@
+package hops_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestThrottlerRejectProbability(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		requests int
+		accepts  int
+		want     float64
+	}{
+		"idle":          {0, 0, 0},
+		"healthy":       {100, 100, 0},
+		"within_k":      {100, 50, 0},
+		"rejecting":     {99, 20, 59.0 / 100},
+		"down":          {99, 0, 99.0 / 100},
+		"barely_within": {10, 5, 0},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			th := hops.NewManualThrottler(10, time.Second, 2, start)
+			for i := 0; i < tt.requests; i++ {
+				th.Allow()
+			}
+			for i := 0; i < tt.accepts; i++ {
+				th.Accepted()
+			}
+
+			if got := th.RejectProbability(); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("expected: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestThrottlerAllow(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	th := hops.NewManualThrottler(10, time.Second, 2, start)
+
+	// Every request is sent while the backend accepts them
+	for i := 0; i < 100; i++ {
+		if !th.Allow() {
+			t.Fatalf("request %d: expected no local rejection", i)
+		}
+		th.Accepted()
+	}
+
+	// The backend goes down and the throttler rejects most requests
+	th.Tick(start.Add(10 * time.Second))
+	allowed := 0
+	for i := 0; i < 1000; i++ {
+		if th.Allow() {
+			allowed++
+		}
+	}
+	if allowed > 100 {
+		t.Errorf("expected most requests to be rejected, got %d allowed", allowed)
+	}
+
+	// It recovers once the failures fall out of the window
+	th.Tick(start.Add(20 * time.Second))
+	if got := th.RejectProbability(); got != 0 {
+		t.Errorf("expected: 0, got: %v", got)
+	}
+}
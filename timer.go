@@ -0,0 +1,268 @@
+package hops
+
+import (
+	"math"
+	"math/bits"
+	"sync"
+	"time"
+)
+
+// Layout of the histogram buckets of a Timer. Durations below
+// timerLinearBuckets nanoseconds get a bucket each. Above that, every power
+// of two is split into timerSubBuckets buckets of equal width, which bounds
+// the error of the estimates to 1/timerSubBuckets of the duration.
+const (
+	timerSubBits       = 3
+	timerSubBuckets    = 1 << timerSubBits
+	timerLinearBuckets = 2 * timerSubBuckets
+	timerBuckets       = timerLinearBuckets + (64-timerSubBits-1)*timerSubBuckets
+)
+
+// Timer is a hopping window recorder of durations, such as request
+// latencies, which can be checked against thresholds:
+//
+//	latency := hops.NewTimer(5, time.Minute)
+//	latency.Record(time.Since(start))
+//	...
+//	sloMet := latency.FractionBelow(300*time.Millisecond) >= 0.99
+//
+// Durations are kept in histograms with a relative precision of 12.5%,
+// one for each time unit of the window.
+//
+// It's safe to use the timer concurrently.
+type Timer struct {
+	// Guards slots and tickTime
+	mu sync.Mutex
+
+	// Histograms of each time unit of the window, or of the retention if
+	// it's longer
+	slots *Ring[timerSlot]
+
+	// Set for timers that are advanced explicitly through Tick
+	manual bool
+
+	// Latest time instant passed to Tick
+	tickTime time.Time
+
+	windowSize int
+}
+
+// timerSlot holds the durations recorded in a time unit
+type timerSlot struct {
+	// Allocated on the first duration recorded in the time unit
+	counts *[timerBuckets]uint32
+}
+
+// NewTimer creates a timer with the given window size and time unit.
+//
+// For example, NewTimer(5, time.Minute) creates a timer that keeps track
+// of the durations recorded in the last 5 minutes.
+func NewTimer(windowSize int, timeUnit time.Duration) *Timer {
+	return &Timer{
+		slots:      NewRing[timerSlot](windowSize, timeUnit),
+		windowSize: windowSize,
+	}
+}
+
+// NewManualTimer creates a timer that doesn't follow the wall clock.
+// It only moves forward when the application calls Tick.
+func NewManualTimer(windowSize int, timeUnit time.Duration, now time.Time) *Timer {
+	t := NewTimer(windowSize, timeUnit)
+	t.manual = true
+	t.tickTime = now
+	return t
+}
+
+// Record adds a duration to the window at the current moment in time.
+// Negative durations are recorded as 0.
+func (t *Timer) Record(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	slot := t.slots.Bucket(t.now())
+	if slot == nil {
+		// The wall clock went back past the retention
+		return
+	}
+	if slot.counts == nil {
+		slot.counts = new([timerBuckets]uint32)
+	}
+	slot.counts[timerBucket(d)]++
+}
+
+// Count returns the number of durations recorded within the window
+func (t *Timer) Count() int {
+	n := 0
+	t.each(func(counts *[timerBuckets]uint32) {
+		for _, c := range counts {
+			n += int(c)
+		}
+	})
+	return n
+}
+
+// FractionBelow returns the share of the durations within the window that
+// are shorter than threshold, between 0 and 1, or 1 if there are none.
+//
+// Durations in the same histogram bucket as the threshold are assumed to
+// be spread evenly across the bucket.
+func (t *Timer) FractionBelow(threshold time.Duration) float64 {
+	threshold = max(threshold, 0)
+
+	var below, total float64
+	b := timerBucket(threshold)
+	lo, hi := timerBucketBounds(b)
+	t.each(func(counts *[timerBuckets]uint32) {
+		for i, c := range counts {
+			total += float64(c)
+			if i < b {
+				below += float64(c)
+			}
+		}
+		// Interpolate within the bucket of the threshold. The bounds of the
+		// last bucket overflow, but it's beyond any practical threshold.
+		if hi > lo {
+			below += float64(counts[b]) * float64(threshold-lo) / float64(hi-lo)
+		}
+	})
+
+	if total == 0 {
+		return 1
+	}
+	return math.Min(below/total, 1)
+}
+
+// TimerHistogram holds the durations recorded in a time unit
+type TimerHistogram struct {
+	// Beginning of the time unit
+	Start time.Time
+
+	// Buckets with at least one duration, in increasing order of durations
+	Buckets []TimerBucket
+}
+
+// TimerBucket is a histogram bucket of durations
+type TimerBucket struct {
+	// Durations in the bucket are at least Min and less than Max
+	Min, Max time.Duration
+
+	Count uint32
+}
+
+// SetRetention keeps the histograms of the given number of most recent
+// time units, even beyond the window, so they can be retrieved with
+// Histograms. The retention is never shorter than the window.
+func (t *Timer) SetRetention(units int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	units = max(units, t.windowSize)
+	if units == t.slots.Size() {
+		return
+	}
+
+	// Move the histograms still retained to the new ring, oldest first, so
+	// the ones beyond the new retention are overwritten
+	slots := NewRing[timerSlot](units, t.slots.Unit())
+	for start, slot := range t.slots.All(t.now()) {
+		*slots.Bucket(start) = *slot
+	}
+	t.slots = slots
+}
+
+// Histograms returns the histogram of every retained time unit, oldest
+// first, e.g. to render a latency heatmap. Time units without durations
+// have no buckets.
+func (t *Timer) Histograms() []TimerHistogram {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now, unit := t.now(), t.slots.Unit()
+	first := time.Unix(0, now.UnixNano()/int64(unit)*int64(unit)).Add(-time.Duration(t.slots.Size()-1) * unit)
+	histograms := make([]TimerHistogram, t.slots.Size())
+	for i := range histograms {
+		histograms[i].Start = first.Add(time.Duration(i) * unit)
+	}
+
+	for start, slot := range t.slots.All(now) {
+		if slot.counts == nil {
+			continue
+		}
+		h := &histograms[start.Sub(first)/unit]
+		for b, c := range slot.counts {
+			if c > 0 {
+				lo, hi := timerBucketBounds(b)
+				h.Buckets = append(h.Buckets, TimerBucket{Min: lo, Max: hi, Count: c})
+			}
+		}
+	}
+	return histograms
+}
+
+// Tick advances a manual timer to the given time instant. Time instants
+// older than the latest one passed to Tick are ignored.
+//
+// Tick has no effect on timers that aren't created by NewManualTimer.
+func (t *Timer) Tick(now time.Time) {
+	if !t.manual {
+		return
+	}
+
+	t.mu.Lock()
+	if now.After(t.tickTime) {
+		t.tickTime = now
+	}
+	t.mu.Unlock()
+}
+
+// each calls fn with the histogram of every time unit within the window.
+// fn is called with mu held.
+func (t *Timer) each(fn func(counts *[timerBuckets]uint32)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// The ring may retain time units beyond the window
+	now := t.now()
+	window := time.Duration(t.windowSize) * t.slots.Unit()
+	for start, slot := range t.slots.All(now) {
+		if slot.counts != nil && now.Sub(start) < window {
+			fn(slot.counts)
+		}
+	}
+}
+
+// now returns the current time instant as seen by the timer.
+// It must be called with mu held.
+func (t *Timer) now() time.Time {
+	if !t.manual {
+		return time.Now()
+	}
+	return t.tickTime
+}
+
+// timerBucket returns the histogram bucket of d
+func timerBucket(d time.Duration) int {
+	if d < timerLinearBuckets {
+		return int(max(d, 0))
+	}
+
+	// Split the power of two of d by its most significant bits
+	v := uint64(d)
+	exp := bits.Len64(v) - 1
+	sub := int(v>>(exp-timerSubBits)) & (timerSubBuckets - 1)
+	return timerLinearBuckets + (exp-timerSubBits-1)*timerSubBuckets + sub
+}
+
+// timerBucketBounds returns the durations covered by bucket b, from lo
+// (inclusive) to hi (exclusive)
+func timerBucketBounds(b int) (lo, hi time.Duration) {
+	if b < timerLinearBuckets {
+		return time.Duration(b), time.Duration(b + 1)
+	}
+
+	exp := (b-timerLinearBuckets)/timerSubBuckets + timerSubBits + 1
+	sub := (b - timerLinearBuckets) % timerSubBuckets
+	width := uint64(1) << (exp - timerSubBits)
+	start := uint64(1)<<exp + uint64(sub)*width
+	return time.Duration(start), time.Duration(start + width)
+}
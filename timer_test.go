@@ -0,0 +1,105 @@
+package hops
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTimerBuckets(t *testing.T) {
+	for _, d := range []time.Duration{
+		0, 1, 15, 16, 17, 31, 32, 100, 999, time.Millisecond,
+		300 * time.Millisecond, time.Hour, math.MaxInt64,
+	} {
+		b := timerBucket(d)
+		lo, hi := timerBucketBounds(b)
+		if b < 0 || b >= timerBuckets {
+			t.Fatalf("%v: bucket %d out of range", d, b)
+		}
+		if d < lo || (d >= hi && hi > lo) {
+			t.Errorf("%v: expected to be in [%v, %v)", d, lo, hi)
+		}
+	}
+}
+
+func TestTimerFractionBelow(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+	timer := NewManualTimer(3, time.Second, start)
+
+	if got := timer.FractionBelow(time.Second); got != 1 {
+		t.Errorf("expected: 1 without durations, got: %v", got)
+	}
+
+	for i := 1; i <= 100; i++ {
+		timer.Record(time.Duration(i) * 10 * time.Millisecond)
+	}
+
+	tests := map[string]struct {
+		threshold time.Duration
+		want      float64
+	}{
+		"none":     {time.Millisecond, 0},
+		"some":     {300 * time.Millisecond, 0.3},
+		"most":     {900 * time.Millisecond, 0.9},
+		"all":      {time.Minute, 1},
+		"negative": {-time.Second, 0},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Within the precision of the histogram buckets
+			if got := timer.FractionBelow(tt.threshold); math.Abs(got-tt.want) > 0.125*tt.want+0.01 {
+				t.Errorf("expected: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+
+	if got := timer.Count(); got != 100 {
+		t.Errorf("expected: 100, got: %d", got)
+	}
+
+	// The durations fall out of the window
+	timer.Tick(start.Add(3 * time.Second))
+	timer.Record(time.Second)
+	if got := timer.Count(); got != 1 {
+		t.Errorf("expected: 1, got: %d", got)
+	}
+	if got := timer.FractionBelow(time.Minute); got != 1 {
+		t.Errorf("expected: 1, got: %v", got)
+	}
+}
+
+func TestTimerHistograms(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+	timer := NewManualTimer(2, time.Second, start)
+	timer.Record(10)
+	timer.Tick(start.Add(time.Second))
+	timer.Record(10)
+	timer.Record(10)
+
+	// The retained histograms outlive the window
+	timer.SetRetention(4)
+	timer.Tick(start.Add(3 * time.Second))
+	timer.Record(20)
+
+	want := []TimerHistogram{
+		{Start: start, Buckets: []TimerBucket{{Min: 10, Max: 11, Count: 1}}},
+		{Start: start.Add(time.Second), Buckets: []TimerBucket{{Min: 10, Max: 11, Count: 2}}},
+		{Start: start.Add(2 * time.Second)},
+		{Start: start.Add(3 * time.Second), Buckets: []TimerBucket{{Min: 20, Max: 22, Count: 1}}},
+	}
+	got := timer.Histograms()
+	if len(got) != len(want) {
+		t.Fatalf("expected: %d histograms, got: %d", len(want), len(got))
+	}
+	for i := range want {
+		if !got[i].Start.Equal(want[i].Start) || !reflect.DeepEqual(got[i].Buckets, want[i].Buckets) {
+			t.Errorf("histogram %d: expected: %v, got: %v", i, want[i], got[i])
+		}
+	}
+
+	if got := timer.Count(); got != 1 {
+		t.Errorf("expected only the window to be counted, got: %d", got)
+	}
+}
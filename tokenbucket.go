@@ -0,0 +1,168 @@
+package hops
+
+import (
+	"sync"
+	"time"
+)
+
+// TokenBucket is a token bucket rate limiter. The bucket holds up to burst
+// tokens and refills at a constant rate. Each event takes one token, and
+// events are denied while the bucket is empty.
+//
+// Unlike WindowLimiter, it smooths bursts out over time instead of allowing
+// the whole limit at the beginning of a window.
+//
+// It's safe to use the limiter concurrently.
+type TokenBucket struct {
+	// Guards all the fields below
+	mu sync.Mutex
+
+	// Tokens added per second
+	rate float64
+
+	// Capacity of the bucket
+	burst int
+
+	// Seconds it takes an empty bucket to refill, as of the latest call to
+	// SetLimit, kept so the limit can be raised again after dropping to 0
+	refillTime float64
+
+	// Tokens in the bucket at time last
+	tokens float64
+	last   time.Time
+
+	// Set for buckets that are advanced explicitly through Tick
+	manual bool
+
+	// Latest time instant passed to Tick
+	tickTime time.Time
+}
+
+// NewTokenBucket creates a full token bucket that refills with rate tokens
+// per second, up to burst tokens.
+//
+// For example, NewTokenBucket(10, 50) allows 10 events per second on
+// average, and bursts of up to 50 events.
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	return &TokenBucket{
+		rate:   rate,
+		burst:  burst,
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// NewManualTokenBucket creates a token bucket that doesn't follow the wall
+// clock. It only refills when the application calls Tick.
+func NewManualTokenBucket(rate float64, burst int, now time.Time) *TokenBucket {
+	b := NewTokenBucket(rate, burst)
+	b.manual = true
+	b.tickTime = now
+	b.last = now
+	return b
+}
+
+// Allow reports whether an event may happen now, and takes a token if so
+func (b *TokenBucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Status returns the state of the bucket at the current moment in time.
+// Reset is the time until the bucket is full again.
+func (b *TokenBucket) Status() LimitStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	status := LimitStatus{Limit: b.burst, Remaining: int(b.tokens)}
+	if missing := float64(b.burst) - b.tokens; missing > 0 && b.rate > 0 {
+		status.Reset = time.Duration(missing / b.rate * float64(time.Second))
+	}
+	return status
+}
+
+// SetRate changes the number of tokens added per second. Tokens accumulated
+// at the old rate are kept.
+func (b *TokenBucket) SetRate(rate float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	b.rate = rate
+}
+
+// SetBurst changes the capacity of the bucket. Tokens in the bucket are
+// kept, up to the new capacity.
+func (b *TokenBucket) SetBurst(burst int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	b.burst = burst
+	if b.tokens > float64(burst) {
+		b.tokens = float64(burst)
+	}
+}
+
+// SetLimit changes the capacity of the bucket to limit, and scales the rate
+// such that an empty bucket still refills in the same time. That's the
+// limit of LimiterConfig, where the bucket holds Limit tokens and refills
+// over Window. Tokens in the bucket are kept, up to the new capacity.
+//
+// A limit of 0 or less denies all events. Raising the limit again restores
+// the refill time.
+func (b *TokenBucket) SetLimit(limit int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	limit = max(0, limit)
+	if b.burst > 0 && b.rate > 0 {
+		b.refillTime = float64(b.burst) / b.rate
+	}
+	if b.refillTime > 0 {
+		b.rate = float64(limit) / b.refillTime
+	}
+	b.burst = limit
+	if b.tokens > float64(limit) {
+		b.tokens = float64(limit)
+	}
+}
+
+// Tick advances a manual bucket to the given time instant. Time instants
+// older than the latest one passed to Tick are ignored.
+//
+// Tick has no effect on buckets that aren't created by NewManualTokenBucket.
+func (b *TokenBucket) Tick(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.manual && now.After(b.tickTime) {
+		b.tickTime = now
+	}
+}
+
+// refill adds the tokens accumulated since the last refill.
+// Must be called with mu held.
+func (b *TokenBucket) refill() {
+	now := b.tickTime
+	if !b.manual {
+		now = time.Now()
+	}
+
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > float64(b.burst) {
+			b.tokens = float64(b.burst)
+		}
+		b.last = now
+	}
+}
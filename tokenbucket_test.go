@@ -0,0 +1,108 @@
+package hops_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestTokenBucket(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	b := hops.NewManualTokenBucket(2, 3, start)
+
+	for i := 0; i < 3; i++ {
+		if !b.Allow() {
+			t.Fatalf("expected event %d of the burst to be allowed", i)
+		}
+	}
+	if b.Allow() {
+		t.Errorf("expected an empty bucket to deny events")
+	}
+
+	status := b.Status()
+	if status.Limit != 3 || status.Remaining != 0 || status.Reset != 1500*time.Millisecond {
+		t.Errorf("expected limit: 3, remaining: 0, reset: 1.5s, got: %+v", status)
+	}
+
+	// Refills at 2 tokens per second
+	b.Tick(start.Add(500 * time.Millisecond))
+	if !b.Allow() {
+		t.Errorf("expected a refilled token to be allowed")
+	}
+	if b.Allow() {
+		t.Errorf("expected only one token to be refilled")
+	}
+
+	// Never holds more than burst tokens
+	b.Tick(start.Add(time.Hour))
+	if got := b.Status().Remaining; got != 3 {
+		t.Errorf("expected remaining: 3, got: %d", got)
+	}
+}
+
+func TestLimiterConfig(t *testing.T) {
+	tests := map[string]struct {
+		cfg     hops.LimiterConfig
+		wantErr bool
+	}{
+		"sliding_window": {
+			hops.LimiterConfig{Algorithm: hops.SlidingWindow, Limit: 10, Window: time.Minute, Unit: time.Second},
+			false,
+		},
+		"default_algorithm_and_unit": {
+			hops.LimiterConfig{Limit: 10, Window: time.Minute},
+			false,
+		},
+		"token_bucket": {
+			hops.LimiterConfig{Algorithm: hops.TokenBucketAlgorithm, Limit: 10, Window: time.Minute},
+			false,
+		},
+		"gcra": {
+			hops.LimiterConfig{Algorithm: hops.GCRAAlgorithm, Limit: 10, Window: time.Minute},
+			false,
+		},
+		"sliding_log": {
+			hops.LimiterConfig{Algorithm: hops.SlidingLogAlgorithm, Limit: 10, Window: time.Minute},
+			false,
+		},
+		"unit_does_not_divide_window": {
+			hops.LimiterConfig{Limit: 10, Window: time.Minute, Unit: 7 * time.Second},
+			true,
+		},
+		"missing_limit": {
+			hops.LimiterConfig{Window: time.Minute},
+			true,
+		},
+		"unknown_algorithm": {
+			hops.LimiterConfig{Algorithm: "leaky", Limit: 10, Window: time.Minute},
+			true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			l, err := tt.cfg.NewLimiter()
+			if tt.wantErr {
+				if !errors.Is(err, hops.ErrInvalidConfig) {
+					t.Errorf("expected: %v, got: %v", hops.ErrInvalidConfig, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Both algorithms allow the whole limit at once
+			for i := 0; i < tt.cfg.Limit; i++ {
+				if !l.Allow() {
+					t.Fatalf("expected event %d to be allowed", i)
+				}
+			}
+			if l.Allow() {
+				t.Errorf("expected events over the limit to be denied")
+			}
+		})
+	}
+}
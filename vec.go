@@ -0,0 +1,270 @@
+package hops
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LabelExtractor returns the label values of an observation made with the
+// given context, in the same order as the label names of the CounterVec.
+type LabelExtractor func(ctx context.Context) []string
+
+// CounterVec is a collection of counters that share the same window size
+// and time unit, partitioned by a set of labels.
+//
+// For example, a CounterVec with the labels "method" and "status" keeps a
+// separate counter for each method and status pair seen in HTTP requests.
+//
+// It's safe to use this counter vector concurrently.
+type CounterVec struct {
+	// Guards children, overflow, maxCardinality, extractor and tickTime
+	mu sync.RWMutex
+
+	// Counters for each combination of label values, keyed by the joined
+	// label values
+	children map[string]*vecChild
+
+	// Shared counter of the label values beyond maxCardinality, also held
+	// in children
+	overflow *vecChild
+
+	// Maximum number of counters, not counting overflow, or 0 if unlimited
+	maxCardinality int
+
+	extractor LabelExtractor
+
+	// Set for vectors that are advanced explicitly through Tick
+	manual bool
+
+	// Latest time instant passed to Tick
+	tickTime time.Time
+
+	labelNames []string
+	windowSize int
+	unit       time.Duration
+}
+
+type vecChild struct {
+	labelValues []string
+	counter     *Counter
+}
+
+// NewCounterVec creates a new counter vector with the given window size,
+// time unit and label names.
+//
+// For example, NewCounterVec(5, time.Minute, "method", "status") creates
+// a vector that keeps track of how many requests with each method and
+// status happened in the last 5 minutes.
+func NewCounterVec(windowSize int, timeUnit time.Duration, labelNames ...string) *CounterVec {
+	return &CounterVec{
+		children:   make(map[string]*vecChild),
+		labelNames: labelNames,
+		windowSize: windowSize,
+		unit:       timeUnit,
+	}
+}
+
+// NewManualCounterVec creates a counter vector whose counters don't follow
+// the wall clock. They only move forward when the application calls Tick.
+func NewManualCounterVec(windowSize int, timeUnit time.Duration, now time.Time, labelNames ...string) *CounterVec {
+	v := NewCounterVec(windowSize, timeUnit, labelNames...)
+	v.manual = true
+	v.tickTime = now
+	return v
+}
+
+// LabelNames returns the label names of the vector
+func (v *CounterVec) LabelNames() []string {
+	return append([]string(nil), v.labelNames...)
+}
+
+// OverflowLabelValue is the value of every label of the counter that holds
+// the events beyond the cardinality limit of a CounterVec
+const OverflowLabelValue = "__overflow__"
+
+// SetMaxCardinality limits the number of counters the vector holds, to
+// protect memory from an explosion of label values, e.g. when a label is
+// derived from user input. Once the limit is reached, events of new label
+// values are counted by a shared counter whose labels all have the value
+// OverflowLabelValue. Existing counters are kept even if they're over the
+// limit. A limit of 0 removes the limit.
+func (v *CounterVec) SetMaxCardinality(n int) {
+	v.mu.Lock()
+	v.maxCardinality = n
+	v.mu.Unlock()
+}
+
+// WithLabelValues returns the counter for the given label values, creating
+// it if needed. The values must be given in the same order as the label
+// names of the vector. Beyond the limit set by SetMaxCardinality, it
+// returns the overflow counter instead of creating a new one.
+//
+// It panics if the number of values doesn't match the number of labels.
+func (v *CounterVec) WithLabelValues(values ...string) *Counter {
+	if len(values) != len(v.labelNames) {
+		panic(fmt.Sprintf("hops: expected %d label values, got %d", len(v.labelNames), len(values)))
+	}
+
+	key := strings.Join(values, "\xff")
+
+	v.mu.RLock()
+	child, ok := v.children[key]
+	if !ok && v.isFull() && v.overflow != nil {
+		child, ok = v.overflow, true
+	}
+	v.mu.RUnlock()
+	if ok {
+		return child.counter
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	// Another goroutine might have created it in the meantime
+	if child, ok := v.children[key]; ok {
+		return child.counter
+	}
+
+	if v.isFull() {
+		if v.overflow == nil {
+			labelValues := make([]string, len(v.labelNames))
+			for i := range labelValues {
+				labelValues[i] = OverflowLabelValue
+			}
+			v.overflow = v.newChild(labelValues)
+			v.children[strings.Join(labelValues, "\xff")] = v.overflow
+		}
+		return v.overflow.counter
+	}
+
+	child = v.newChild(append([]string(nil), values...))
+	v.children[key] = child
+
+	return child.counter
+}
+
+// isFull reports whether the vector reached its cardinality limit.
+// It must be called with mu held.
+func (v *CounterVec) isFull() bool {
+	n := len(v.children)
+	if v.overflow != nil {
+		n--
+	}
+	return v.maxCardinality > 0 && n >= v.maxCardinality
+}
+
+// newChild creates the counter of the given label values.
+// It must be called with mu held.
+func (v *CounterVec) newChild(labelValues []string) *vecChild {
+	child := &vecChild{labelValues: labelValues}
+	if v.manual {
+		child.counter = NewManualCounter(v.windowSize, v.unit, v.tickTime)
+	} else {
+		child.counter = NewCounter(v.windowSize, v.unit)
+	}
+	return child
+}
+
+// VecSample is the window of the counter with the given label values
+type VecSample struct {
+	LabelValues []string
+	Snapshot    Snapshot
+}
+
+// Range calls fn with the label values and the number of events within the
+// window of each counter, in sorted order of label values. It's safe to use
+// the vector from fn.
+func (v *CounterVec) Range(fn func(labelValues []string, value int)) {
+	for _, child := range v.sortedChildren() {
+		fn(append([]string(nil), child.labelValues...), child.counter.Value())
+	}
+}
+
+// Snapshot returns the window of each counter at the current moment in
+// time, in sorted order of label values, e.g. for exporters and debug
+// handlers
+func (v *CounterVec) Snapshot() []VecSample {
+	children := v.sortedChildren()
+	samples := make([]VecSample, len(children))
+	for i, child := range children {
+		samples[i] = VecSample{
+			LabelValues: append([]string(nil), child.labelValues...),
+			Snapshot:    child.counter.Snapshot(),
+		}
+	}
+	return samples
+}
+
+// sortedChildren returns the counters of the vector, in sorted order of
+// label values
+func (v *CounterVec) sortedChildren() []*vecChild {
+	v.mu.RLock()
+	children := make([]*vecChild, 0, len(v.children))
+	for _, child := range v.children {
+		children = append(children, child)
+	}
+	v.mu.RUnlock()
+
+	slices.SortFunc(children, func(a, b *vecChild) int {
+		return slices.Compare(a.labelValues, b.labelValues)
+	})
+	return children
+}
+
+// SetLabelExtractor registers the function used by ObserveContext to
+// extract label values from a context
+func (v *CounterVec) SetLabelExtractor(fn LabelExtractor) {
+	v.mu.Lock()
+	v.extractor = fn
+	v.mu.Unlock()
+}
+
+// ObserveContext adds an event to the counter whose label values are
+// extracted from ctx by the registered LabelExtractor.
+//
+// This way, code deep down the call stack can record events without having
+// every label threaded through its function signatures. Labels missing from
+// the extracted values are recorded as empty strings, and extra values are
+// ignored. If no extractor is registered, all labels are empty.
+func (v *CounterVec) ObserveContext(ctx context.Context) {
+	v.mu.RLock()
+	extractor := v.extractor
+	v.mu.RUnlock()
+
+	values := make([]string, len(v.labelNames))
+	if extractor != nil {
+		copy(values, extractor(ctx))
+	}
+	v.WithLabelValues(values...).Observe()
+}
+
+// Tick advances all counters of a manual vector to the given time instant.
+//
+// Tick has no effect on vectors that aren't created by NewManualCounterVec.
+func (v *CounterVec) Tick(now time.Time) {
+	if !v.manual {
+		return
+	}
+
+	v.mu.Lock()
+	if now.Before(v.tickTime) {
+		v.mu.Unlock()
+		return
+	}
+	v.tickTime = now
+	children := make([]*vecChild, 0, len(v.children))
+	for _, child := range v.children {
+		children = append(children, child)
+	}
+	v.mu.Unlock()
+
+	// Tick without holding the lock, since it calls the OnIdle watchers of
+	// the children, which may use the vector as well
+	for _, child := range children {
+		child.counter.Tick(now)
+	}
+}
@@ -0,0 +1,161 @@
+package hops_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+type tenantKey struct{}
+
+func TestCounterVecObserveContext(t *testing.T) {
+	v := hops.NewCounterVec(5, time.Minute, "tenant", "class")
+	v.SetLabelExtractor(func(ctx context.Context) []string {
+		tenant, _ := ctx.Value(tenantKey{}).(string)
+		return []string{tenant, "api"}
+	})
+
+	acme := context.WithValue(context.Background(), tenantKey{}, "acme")
+	v.ObserveContext(acme)
+	v.ObserveContext(acme)
+	v.ObserveContext(context.Background())
+
+	tests := map[string]struct {
+		labelValues []string
+		want        int
+	}{
+		"tenant_from_context": {[]string{"acme", "api"}, 2},
+		"missing_tenant":      {[]string{"", "api"}, 1},
+		"never_observed":      {[]string{"other", "api"}, 0},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := v.WithLabelValues(tt.labelValues...).Value(); got != tt.want {
+				t.Errorf("expected: %d, got: %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCounterVecWithoutExtractor(t *testing.T) {
+	v := hops.NewCounterVec(5, time.Minute, "tenant")
+	v.ObserveContext(context.Background())
+
+	if got := v.WithLabelValues("").Value(); got != 1 {
+		t.Errorf("expected: 1, got: %d", got)
+	}
+}
+
+func TestCounterVecWrongNumberOfLabelValues(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic")
+		}
+	}()
+
+	v := hops.NewCounterVec(5, time.Minute, "method", "status")
+	v.WithLabelValues("GET")
+}
+
+func TestManualCounterVec(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+	v := hops.NewManualCounterVec(2, time.Second, start, "method")
+
+	v.WithLabelValues("GET").Observe()
+	v.Tick(start.Add(time.Second))
+	v.WithLabelValues("POST").Observe()
+	v.Tick(start.Add(2 * time.Second))
+
+	if got := v.WithLabelValues("GET").Value(); got != 0 {
+		t.Errorf("expected GET: 0, got: %d", got)
+	}
+	if got := v.WithLabelValues("POST").Value(); got != 1 {
+		t.Errorf("expected POST: 1, got: %d", got)
+	}
+}
+
+func TestManualCounterVecTickOnIdle(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+	v := hops.NewManualCounterVec(2, time.Second, start, "method")
+
+	v.WithLabelValues("GET").Observe()
+	v.WithLabelValues("GET").OnIdle(time.Second, func() {
+		v.WithLabelValues("idle").Observe()
+	})
+	v.Tick(start.Add(2 * time.Second))
+
+	if got := v.WithLabelValues("idle").Value(); got != 1 {
+		t.Errorf("expected idle: 1, got: %d", got)
+	}
+}
+
+func TestCounterVecMaxCardinality(t *testing.T) {
+	v := hops.NewCounterVec(5, time.Minute, "client", "path")
+	v.SetMaxCardinality(2)
+
+	v.WithLabelValues("a", "/").Observe()
+	v.WithLabelValues("b", "/").Observe()
+	v.WithLabelValues("c", "/").Observe()
+	v.WithLabelValues("d", "/").Observe()
+	v.WithLabelValues("a", "/").Observe()
+
+	tests := map[string]struct {
+		labelValues []string
+		want        int
+	}{
+		"under_limit": {[]string{"a", "/"}, 2},
+		"at_limit":    {[]string{"b", "/"}, 1},
+		"overflow":    {[]string{hops.OverflowLabelValue, hops.OverflowLabelValue}, 2},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := v.WithLabelValues(tt.labelValues...).Value(); got != tt.want {
+				t.Errorf("expected: %d, got: %d", tt.want, got)
+			}
+		})
+	}
+
+	if got := v.WithLabelValues("e", "/"); got != v.WithLabelValues("c", "/") {
+		t.Errorf("expected new label values to share the overflow counter")
+	}
+	if got := v.KeyStats().Keys; got != 3 {
+		t.Errorf("expected: 3 counters, got: %d", got)
+	}
+}
+
+func TestCounterVecRange(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+	v := hops.NewManualCounterVec(3, time.Second, start, "method", "status")
+	v.WithLabelValues("POST", "201").Observe()
+	v.WithLabelValues("GET", "200").Observe()
+	v.WithLabelValues("GET", "200").Observe()
+	v.WithLabelValues("GET", "500")
+
+	var got []string
+	v.Range(func(labelValues []string, value int) {
+		got = append(got, fmt.Sprintf("%s=%d", strings.Join(labelValues, ","), value))
+	})
+	want := []string{"GET,200=2", "GET,500=0", "POST,201=1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected: %v, got: %v", want, got)
+	}
+
+	samples := v.Snapshot()
+	if len(samples) != 3 {
+		t.Fatalf("expected: 3 samples, got: %d", len(samples))
+	}
+	first := samples[0]
+	if !reflect.DeepEqual(first.LabelValues, []string{"GET", "200"}) {
+		t.Errorf("expected the samples in sorted order, got: %v first", first.LabelValues)
+	}
+	if want := []uint32{0, 0, 2}; !reflect.DeepEqual(first.Snapshot.Counts, want) {
+		t.Errorf("expected: %v, got: %v", want, first.Snapshot.Counts)
+	}
+}
@@ -0,0 +1,146 @@
+package hops
+
+import (
+	"container/heap"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// KeyStats describes how the events within the window are spread across
+// the counters of a CounterVec
+type KeyStats struct {
+	// Number of counters, including the ones without events in the window
+	Keys int
+
+	// Median number of events per counter
+	P50 int
+
+	// 95th percentile of the number of events per counter
+	P95 int
+
+	// Largest number of events of a counter
+	Max int
+
+	// Number of events across all counters
+	Total int
+}
+
+// KeyStats returns statistics of the number of events within the window of
+// each counter, e.g. to tell whether load is evenly spread across clients
+// or a few of them dominate. Percentiles use the nearest-rank method.
+func (v *CounterVec) KeyStats() KeyStats {
+	v.mu.RLock()
+	values := make([]int, 0, len(v.children))
+	for _, child := range v.children {
+		values = append(values, child.counter.Value())
+	}
+	v.mu.RUnlock()
+
+	stats := KeyStats{Keys: len(values)}
+	if len(values) == 0 {
+		return stats
+	}
+
+	sort.Ints(values)
+	for _, n := range values {
+		stats.Total += n
+	}
+	stats.P50 = nearestRank(values, 50)
+	stats.P95 = nearestRank(values, 95)
+	stats.Max = values[len(values)-1]
+	return stats
+}
+
+// nearestRank returns the p-th percentile of the sorted, non-empty values
+func nearestRank(values []int, p int) int {
+	rank := (p*len(values) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return values[rank-1]
+}
+
+// KeyValue is the number of events within the window of the counter with
+// the given label values
+type KeyValue struct {
+	LabelValues []string
+	Value       int
+}
+
+// TopN returns the n counters with the most events within the window, in
+// decreasing order of their number of events, e.g. for the ten noisiest
+// clients of the last five minutes. Ties are broken by label values.
+//
+// It keeps only n counters in memory at a time, no matter how many
+// counters the vector holds.
+func (v *CounterVec) TopN(n int) []KeyValue {
+	if n <= 0 {
+		return nil
+	}
+
+	top := make(keyValueHeap, 0, n)
+	v.mu.RLock()
+	for _, child := range v.children {
+		kv := KeyValue{LabelValues: child.labelValues, Value: child.counter.Value()}
+		if len(top) < n {
+			heap.Push(&top, kv)
+		} else if top.less(top[0], kv) {
+			top[0] = kv
+			heap.Fix(&top, 0)
+		}
+	}
+	v.mu.RUnlock()
+
+	// Pop the smallest first, filling the result from its end
+	result := make([]KeyValue, len(top))
+	for i := len(result) - 1; i >= 0; i-- {
+		kv := heap.Pop(&top).(KeyValue)
+		result[i] = KeyValue{LabelValues: append([]string(nil), kv.LabelValues...), Value: kv.Value}
+	}
+	return result
+}
+
+// keyValueHeap is a min-heap of counters, ordered by number of events
+type keyValueHeap []KeyValue
+
+// less reports whether a ranks lower than b
+func (h keyValueHeap) less(a, b KeyValue) bool {
+	if a.Value != b.Value {
+		return a.Value < b.Value
+	}
+	return slices.Compare(a.LabelValues, b.LabelValues) > 0
+}
+
+func (h keyValueHeap) Len() int           { return len(h) }
+func (h keyValueHeap) Less(i, j int) bool { return h.less(h[i], h[j]) }
+func (h keyValueHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *keyValueHeap) Push(x any)        { *h = append(*h, x.(KeyValue)) }
+
+func (h *keyValueHeap) Pop() any {
+	old := *h
+	kv := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return kv
+}
+
+// SumBy returns the number of events within the window for each value of
+// the given label, summed over the other labels. For example, with the
+// labels "method" and "status", SumBy("method") returns the number of
+// requests of each method, no matter their status.
+//
+// It panics if the vector has no such label.
+func (v *CounterVec) SumBy(label string) map[string]int {
+	i := slices.Index(v.labelNames, label)
+	if i < 0 {
+		panic(fmt.Sprintf("hops: unknown label %q", label))
+	}
+
+	sums := make(map[string]int)
+	v.mu.RLock()
+	for _, child := range v.children {
+		sums[child.labelValues[i]] += child.counter.Value()
+	}
+	v.mu.RUnlock()
+	return sums
+}
@@ -0,0 +1,129 @@
+package hops_test
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestCounterVecKeyStats(t *testing.T) {
+	tests := map[string]struct {
+		counts []int
+		want   hops.KeyStats
+	}{
+		"empty": {nil, hops.KeyStats{}},
+		"single": {
+			[]int{4},
+			hops.KeyStats{Keys: 1, P50: 4, P95: 4, Max: 4, Total: 4},
+		},
+		"even": {
+			[]int{3, 3, 3, 3},
+			hops.KeyStats{Keys: 4, P50: 3, P95: 3, Max: 3, Total: 12},
+		},
+		"one_dominates": {
+			[]int{1, 0, 2, 1, 1, 0, 1, 2, 1, 1, 1, 0, 1, 2, 1, 1, 0, 1, 1, 50},
+			hops.KeyStats{Keys: 20, P50: 1, P95: 2, Max: 50, Total: 68},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			v := hops.NewCounterVec(5, time.Minute, "client")
+			for i, n := range tt.counts {
+				c := v.WithLabelValues(strconv.Itoa(i))
+				for j := 0; j < n; j++ {
+					c.Observe()
+				}
+			}
+
+			if got := v.KeyStats(); got != tt.want {
+				t.Errorf("expected: %+v, got: %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCounterVecTopN(t *testing.T) {
+	v := hops.NewCounterVec(5, time.Minute, "client")
+	for client, n := range map[string]int{"a": 5, "b": 1, "c": 9, "d": 5, "e": 0} {
+		c := v.WithLabelValues(client)
+		for i := 0; i < n; i++ {
+			c.Observe()
+		}
+	}
+
+	tests := map[string]struct {
+		n    int
+		want []hops.KeyValue
+	}{
+		"none": {0, nil},
+		"top_one": {1, []hops.KeyValue{
+			{LabelValues: []string{"c"}, Value: 9},
+		}},
+		"ties_by_label": {3, []hops.KeyValue{
+			{LabelValues: []string{"c"}, Value: 9},
+			{LabelValues: []string{"a"}, Value: 5},
+			{LabelValues: []string{"d"}, Value: 5},
+		}},
+		"more_than_keys": {10, []hops.KeyValue{
+			{LabelValues: []string{"c"}, Value: 9},
+			{LabelValues: []string{"a"}, Value: 5},
+			{LabelValues: []string{"d"}, Value: 5},
+			{LabelValues: []string{"b"}, Value: 1},
+			{LabelValues: []string{"e"}, Value: 0},
+		}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := v.TopN(tt.n); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCounterVecSumBy(t *testing.T) {
+	v := hops.NewCounterVec(5, time.Minute, "method", "status")
+	for labels, n := range map[[2]string]int{
+		{"GET", "200"}:  4,
+		{"GET", "500"}:  1,
+		{"POST", "201"}: 2,
+		{"POST", "500"}: 3,
+	} {
+		c := v.WithLabelValues(labels[0], labels[1])
+		for i := 0; i < n; i++ {
+			c.Observe()
+		}
+	}
+
+	tests := map[string]struct {
+		label string
+		want  map[string]int
+	}{
+		"method": {"method", map[string]int{"GET": 5, "POST": 5}},
+		"status": {"status", map[string]int{"200": 4, "201": 2, "500": 4}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := v.SumBy(tt.label); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCounterVecSumByUnknownLabel(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic")
+		}
+	}()
+
+	v := hops.NewCounterVec(5, time.Minute, "method")
+	v.SumBy("status")
+}
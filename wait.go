@@ -0,0 +1,179 @@
+package hops
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Bounds of the time the Wait scheduler sleeps between two rounds
+const (
+	minWaitPoll = time.Millisecond
+	maxWaitPoll = 50 * time.Millisecond
+)
+
+// waiter is a goroutine blocked in KeyedLimiter.Wait
+type waiter struct {
+	// Closed when the waiter is allowed to proceed
+	ready chan struct{}
+}
+
+// waitQueue holds the goroutines blocked in KeyedLimiter.Wait
+type waitQueue struct {
+	// Waiters of each key, in arrival order
+	waiters map[string][]*waiter
+
+	// Keys with waiters, in the order they are served
+	keys []string
+
+	// Set while the scheduler goroutine runs
+	running bool
+
+	// Wakes up the scheduler when a new waiter arrives
+	wake chan struct{}
+}
+
+// Wait blocks until an event for key is allowed, and records it, or until
+// ctx is done, in which case it returns the context's error. It fails right
+// away with ErrLimitExceeded if the limit of key leaves no room for any
+// event, since waiting wouldn't help. Each call
+// counts once in the metrics of the limiter: as allowed once it returns
+// nil, or as denied once it gives up.
+//
+// Waiters are served fairly: in arrival order within a key, and in
+// round-robin order across keys, one event per key per round. This way a
+// key with many blocked goroutines can't starve the others of the capacity
+// freed up by a shared limit.
+func (k *KeyedLimiter) Wait(ctx context.Context, key string) error {
+	k.waitMu.Lock()
+
+	// Fast path, when nobody is queued ahead
+	l := k.Get(key)
+	if len(k.queue.waiters) == 0 {
+		if allowed := l.Allow(); allowed || k.dryRun.Load() {
+			k.waitMu.Unlock()
+			k.decide(key, allowed)
+			return nil
+		}
+	}
+	if l.Status().Limit <= 0 && !k.dryRun.Load() {
+		k.waitMu.Unlock()
+		k.decide(key, false)
+		return fmt.Errorf("%w: key %q", ErrLimitExceeded, key)
+	}
+
+	w := &waiter{ready: make(chan struct{})}
+	if len(k.queue.waiters[key]) == 0 {
+		k.queue.keys = append(k.queue.keys, key)
+	}
+	k.queue.waiters[key] = append(k.queue.waiters[key], w)
+
+	if !k.queue.running {
+		k.queue.running = true
+		go k.schedule()
+	} else {
+		select {
+		case k.queue.wake <- struct{}{}:
+		default:
+		}
+	}
+	k.waitMu.Unlock()
+
+	select {
+	case <-w.ready:
+		k.metrics.Load().record(key, true)
+		return nil
+	case <-ctx.Done():
+	}
+
+	k.waitMu.Lock()
+	defer k.waitMu.Unlock()
+
+	// The waiter might have been served in the meantime
+	select {
+	case <-w.ready:
+		k.metrics.Load().record(key, true)
+		return nil
+	default:
+	}
+
+	queue := k.queue.waiters[key]
+	for i, other := range queue {
+		if other == w {
+			k.queue.waiters[key] = append(queue[:i:i], queue[i+1:]...)
+			break
+		}
+	}
+	if len(k.queue.waiters[key]) == 0 {
+		k.queue.removeKey(key)
+	}
+	k.metrics.Load().record(key, false)
+	return ctx.Err()
+}
+
+// schedule serves the waiters until there are none left
+func (k *KeyedLimiter) schedule() {
+	for {
+		k.waitMu.Lock()
+		if len(k.queue.keys) == 0 {
+			k.queue.running = false
+			k.waitMu.Unlock()
+			return
+		}
+
+		// One round: try to let through the first waiter of every key. The
+		// outcome is recorded by the waiter itself, so polling doesn't count
+		// as denials.
+		served := false
+		sleep := maxWaitPoll
+		for _, key := range append([]string(nil), k.queue.keys...) {
+			l := k.Get(key)
+			if !l.Allow() {
+				if reset := l.Status().Reset; reset < sleep {
+					sleep = reset
+				}
+				continue
+			}
+
+			served = true
+			queue := k.queue.waiters[key]
+			close(queue[0].ready)
+			k.queue.waiters[key] = queue[1:]
+
+			// Move the key to the back of the line
+			k.queue.removeKey(key)
+			if len(k.queue.waiters[key]) > 0 {
+				k.queue.keys = append(k.queue.keys, key)
+			}
+		}
+		k.waitMu.Unlock()
+
+		if served {
+			continue
+		}
+		if sleep < minWaitPoll {
+			sleep = minWaitPoll
+		}
+
+		timer := time.NewTimer(sleep)
+		select {
+		case <-timer.C:
+		case <-k.queue.wake:
+			timer.Stop()
+		}
+	}
+}
+
+// removeKey removes key from the serving order, and its empty queue.
+// Must be called with waitMu held.
+func (q *waitQueue) removeKey(key string) {
+	if len(q.waiters[key]) == 0 {
+		delete(q.waiters, key)
+	}
+	for i, other := range q.keys {
+		if other == key {
+			q.keys = append(q.keys[:i:i], q.keys[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package hops
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWaitFastPath(t *testing.T) {
+	l := NewKeyedLimiter(func() Limiter {
+		return NewWindowLimiter(NewCounter(5, time.Minute), 1)
+	})
+
+	if err := l.Wait(context.Background(), "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, "alice"); err != context.DeadlineExceeded {
+		t.Errorf("expected: %v, got: %v", context.DeadlineExceeded, err)
+	}
+
+	l.waitMu.Lock()
+	defer l.waitMu.Unlock()
+	if len(l.queue.waiters) != 0 || len(l.queue.keys) != 0 {
+		t.Errorf("expected the canceled waiter to leave the queue")
+	}
+}
+
+func TestWaitZeroLimit(t *testing.T) {
+	l := NewKeyedLimiter(func() Limiter {
+		return NewWindowLimiter(NewCounter(5, time.Minute), 0)
+	})
+
+	if err := l.Wait(context.Background(), "alice"); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("expected: %v, got: %v", ErrLimitExceeded, err)
+	}
+	if got := l.Metrics().Denied.Value(); got != 1 {
+		t.Errorf("expected denied: 1, got: %d", got)
+	}
+}
+
+func TestWaitIsFairAcrossKeys(t *testing.T) {
+	// All keys share the same limit, so they compete for capacity
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	c := NewManualCounter(1, time.Second, start)
+	shared := NewWindowLimiter(c, 1)
+	l := NewKeyedLimiter(func() Limiter { return shared })
+	l.Allow("hot")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	wait := func(key string) {
+		defer wg.Done()
+		if err := l.Wait(ctx, key); err != nil {
+			t.Error(err)
+			return
+		}
+		mu.Lock()
+		order = append(order, key)
+		mu.Unlock()
+	}
+	queued := func(n int) {
+		for {
+			l.waitMu.Lock()
+			total := 0
+			for _, q := range l.queue.waiters {
+				total += len(q)
+			}
+			l.waitMu.Unlock()
+			if total == n {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// A hot key queues up a lot of waiters before a cold one arrives
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go wait("hot")
+		queued(i + 1)
+	}
+	wg.Add(2)
+	go wait("cold")
+	go wait("cold")
+	queued(8)
+
+	// Free up capacity for one event at a time
+	for i := 1; i <= 8; i++ {
+		c.Tick(start.Add(time.Duration(i) * time.Second))
+		for {
+			mu.Lock()
+			n := len(order)
+			mu.Unlock()
+			if n == i || ctx.Err() != nil {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	wg.Wait()
+
+	want := []string{"hot", "cold", "hot", "cold", "hot", "hot", "hot", "hot"}
+	for i := range want {
+		if i >= len(order) || order[i] != want[i] {
+			t.Fatalf("expected order: %v, got: %v", want, order)
+		}
+	}
+}
+
+func TestWaitRecordsOneOutcome(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 0, 0, time.UTC)
+	c := NewManualCounter(1, time.Second, start)
+	l := NewKeyedLimiter(func() Limiter { return NewWindowLimiter(c, 1) })
+
+	if err := l.Wait(context.Background(), "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Served after the scheduler polled the limiter a few times
+	done := make(chan error)
+	go func() { done <- l.Wait(context.Background(), "alice") }()
+	time.Sleep(20 * time.Millisecond)
+	c.Tick(start.Add(time.Second))
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// Gives up
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, "alice"); err != context.DeadlineExceeded {
+		t.Fatalf("expected: %v, got: %v", context.DeadlineExceeded, err)
+	}
+
+	m := l.Metrics()
+	if m.Allowed.Value() != 2 || m.Denied.Value() != 1 {
+		t.Errorf("expected allowed: 2, denied: 1, got: %d, %d", m.Allowed.Value(), m.Denied.Value())
+	}
+}
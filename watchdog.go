@@ -0,0 +1,110 @@
+package hops
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Watchdog is a dead-man switch for a counter: it calls a function when the
+// number of events within the counter's window stays below a minimum for a
+// number of consecutive time units.
+//
+// It's the inverse of a threshold alert, meant for heartbeat-style
+// monitoring of producers that are expected to keep emitting events.
+type Watchdog struct {
+	c     *Counter
+	floor int
+	units int
+	fn    func(Snapshot)
+
+	// Guards below and tripped
+	mu sync.Mutex
+
+	// Number of consecutive hops with the window total below the floor
+	below int
+
+	// Set after fn was called, until the window total recovers
+	tripped bool
+
+	// Records trips and recoveries, if set
+	log  CrossingRecorder
+	name string
+}
+
+// NewWatchdog creates a watchdog that calls fn when the window total of c
+// stays below floor for the given number of consecutive time units.
+//
+// fn is called once each time the watchdog trips, with the snapshot of the
+// window that tripped it. The watchdog is re-armed once the window total
+// reaches the floor again.
+func NewWatchdog(c *Counter, floor, units int, fn func(Snapshot)) *Watchdog {
+	return &Watchdog{c: c, floor: floor, units: units, fn: fn}
+}
+
+// Run checks the counter every time its window hops, until ctx is done
+func (w *Watchdog) Run(ctx context.Context) {
+	for s := range w.c.Hops(ctx) {
+		w.check(s)
+	}
+}
+
+// LogTo records every trip and recovery of the watchdog in log, under the
+// given counter name, e.g. a CrossingLog or a Webhook. It must be called
+// before Run.
+func (w *Watchdog) LogTo(log CrossingRecorder, name string) {
+	w.mu.Lock()
+	w.log = log
+	w.name = name
+	w.mu.Unlock()
+}
+
+// Tripped reports whether the window total is currently below the floor
+// for long enough to trip the watchdog
+func (w *Watchdog) Tripped() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.tripped
+}
+
+// check updates the watchdog state with the given snapshot
+func (w *Watchdog) check(s Snapshot) {
+	w.mu.Lock()
+	if s.Total >= w.floor {
+		if w.tripped {
+			w.record(s, true)
+		}
+		w.below = 0
+		w.tripped = false
+		w.mu.Unlock()
+		return
+	}
+
+	w.below++
+	fire := !w.tripped && w.below >= w.units
+	if fire {
+		w.tripped = true
+		w.record(s, false)
+	}
+	w.mu.Unlock()
+
+	if fire {
+		w.fn(s)
+	}
+}
+
+// record adds a trip or a recovery to the log, if any.
+// It must be called with mu held.
+func (w *Watchdog) record(s Snapshot, recovered bool) {
+	if w.log == nil {
+		return
+	}
+	w.log.Record(Crossing{
+		Time:      s.Start.Add(time.Duration(len(s.Counts)) * s.Unit),
+		Counter:   w.name,
+		Value:     s.Total,
+		Threshold: w.floor,
+		Recovered: recovered,
+		Counts:    append([]uint32(nil), s.Counts...),
+	})
+}
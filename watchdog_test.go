@@ -0,0 +1,86 @@
+package hops
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatchdog(t *testing.T) {
+	trips := 0
+	w := NewWatchdog(nil, 10, 3, func(Snapshot) { trips++ })
+
+	tests := []struct {
+		total       int
+		wantTripped bool
+		wantTrips   int
+	}{
+		{12, false, 0},
+		{9, false, 0},
+		{5, false, 0},
+		{0, true, 1},
+		// Stays tripped without firing again
+		{0, true, 1},
+		// Recovers and re-arms
+		{10, false, 1},
+		{1, false, 1},
+		{1, false, 1},
+		{1, true, 2},
+	}
+
+	for i, tt := range tests {
+		w.check(Snapshot{Total: tt.total})
+		if w.Tripped() != tt.wantTripped {
+			t.Errorf("hop %d: expected tripped: %v, got: %v", i, tt.wantTripped, w.Tripped())
+		}
+		if trips != tt.wantTrips {
+			t.Errorf("hop %d: expected trips: %d, got: %d", i, tt.wantTrips, trips)
+		}
+	}
+}
+
+func TestWatchdogRun(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+	c := NewManualCounter(2, time.Second, start)
+
+	tripped := make(chan Snapshot, 1)
+	w := NewWatchdog(c, 1, 2, func(s Snapshot) { tripped <- s })
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	go w.Run(ctx)
+
+	// Keep ticking an idle counter until the watchdog trips
+	now := start
+	for {
+		select {
+		case <-tripped:
+			return
+		case <-ctx.Done():
+			t.Fatal("expected the watchdog to trip")
+		case <-time.After(10 * time.Millisecond):
+			now = now.Add(time.Second)
+			c.Tick(now)
+		}
+	}
+}
+
+func TestWatchdogLogTo(t *testing.T) {
+	start := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+	log := NewCrossingLog(10)
+	w := NewWatchdog(nil, 10, 1, func(Snapshot) {})
+	w.LogTo(log, "heartbeats")
+
+	for i, total := range []int{12, 3, 2, 11} {
+		w.check(Snapshot{Start: start.Add(time.Duration(i) * time.Second), Unit: time.Second, Total: total})
+	}
+
+	want := []Crossing{
+		{Time: start.Add(time.Second), Counter: "heartbeats", Value: 3, Threshold: 10},
+		{Time: start.Add(3 * time.Second), Counter: "heartbeats", Value: 11, Threshold: 10, Recovered: true},
+	}
+	if got := log.Entries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected: %v, got: %v", want, got)
+	}
+}
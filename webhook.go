@@ -0,0 +1,155 @@
+package hops
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Webhook delivers threshold crossings and alerts to HTTP endpoints, e.g.
+// Slack or PagerDuty bridges. Each of them is POSTed to every URL as a
+// JSON object, and failed deliveries are retried with exponential backoff.
+//
+// It's a CrossingRecorder, so a watchdog can alert through it directly:
+//
+//	w.LogTo(hops.NewWebhook("https://alerts.example.com/hops"), "heartbeats")
+//
+// It's also a Notifier for an Alerter.
+//
+// The fields must not be changed once the webhook is in use.
+type Webhook struct {
+	urls []string
+
+	// Client used to send the requests
+	Client *http.Client
+
+	// Number of times a failed delivery is retried
+	Retries int
+
+	// Time to wait before the first retry. It doubles with every retry.
+	Backoff time.Duration
+
+	// Called when a crossing or an alert can't be delivered to a URL, after
+	// the last retry. Optional.
+	OnError func(url string, err error)
+
+	// Tracks deliveries started by Record and Notify
+	wg sync.WaitGroup
+}
+
+// NewWebhook creates a webhook that delivers to the given URLs.
+// Requests time out after 10 seconds, and failed deliveries are retried
+// 3 times, after 1, 2 and 4 seconds.
+func NewWebhook(urls ...string) *Webhook {
+	return &Webhook{
+		urls:    urls,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+		Retries: 3,
+		Backoff: time.Second,
+	}
+}
+
+// Record delivers the crossing in the background, so it never blocks the
+// caller. Failures are reported to OnError.
+func (h *Webhook) Record(c Crossing) {
+	h.background(c)
+}
+
+// Notify delivers the alert in the background, so it never blocks the
+// alerter. Failures are reported to OnError.
+func (h *Webhook) Notify(a Alert) {
+	h.background(a)
+}
+
+// Wait blocks until all the deliveries started by Record and Notify are
+// done, e.g. before the application exits
+func (h *Webhook) Wait() {
+	h.wg.Wait()
+}
+
+// Deliver POSTs the crossing to every URL, retrying failed deliveries,
+// and returns the errors of the URLs it couldn't be delivered to
+func (h *Webhook) Deliver(ctx context.Context, c Crossing) error {
+	return h.send(ctx, c)
+}
+
+// DeliverAlert is like Deliver, but for an alert
+func (h *Webhook) DeliverAlert(ctx context.Context, a Alert) error {
+	return h.send(ctx, a)
+}
+
+// background sends the payload in a new goroutine tracked by wg
+func (h *Webhook) background(payload any) {
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		h.send(context.Background(), payload)
+	}()
+}
+
+// send POSTs the payload, encoded as JSON, to every URL
+func (h *Webhook) send(ctx context.Context, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, url := range h.urls {
+		if err := h.deliver(ctx, url, body); err != nil {
+			if h.OnError != nil {
+				h.OnError(url, err)
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", url, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deliver POSTs the body to the URL until it succeeds or runs out of
+// retries
+func (h *Webhook) deliver(ctx context.Context, url string, body []byte) error {
+	backoff := h.Backoff
+	for attempt := 0; ; attempt++ {
+		err := h.post(ctx, url, body)
+		if err == nil || attempt >= h.Retries {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// post sends a single request, and fails unless the response is a success
+func (h *Webhook) post(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return nil
+}
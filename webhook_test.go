@@ -0,0 +1,117 @@
+package hops_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ocpodariu/hops"
+)
+
+func TestWebhookDeliver(t *testing.T) {
+	crossing := hops.Crossing{
+		Time:      time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC),
+		Counter:   "jobs",
+		Value:     3,
+		Threshold: 10,
+		Counts:    []uint32{2, 1, 0},
+	}
+
+	tests := map[string]struct {
+		failures     int
+		retries      int
+		wantErr      bool
+		wantRequests int
+	}{
+		"delivered":         {0, 3, false, 1},
+		"delivered_retried": {2, 3, false, 3},
+		"out_of_retries":    {5, 2, true, 3},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var mu sync.Mutex
+			var got []hops.Crossing
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				var c hops.Crossing
+				if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				got = append(got, c)
+				if len(got) <= tt.failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			}))
+			defer srv.Close()
+
+			var failed []string
+			h := hops.NewWebhook(srv.URL)
+			h.Retries = tt.retries
+			h.Backoff = time.Millisecond
+			h.OnError = func(url string, err error) { failed = append(failed, url) }
+
+			err := h.Deliver(context.Background(), crossing)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error: %v, got: %v", tt.wantErr, err)
+			}
+			if tt.wantErr && !reflect.DeepEqual(failed, []string{srv.URL}) {
+				t.Errorf("expected: %v, got: %v", []string{srv.URL}, failed)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(got) != tt.wantRequests {
+				t.Fatalf("expected: %d requests, got: %d", tt.wantRequests, len(got))
+			}
+			if !reflect.DeepEqual(got[0], crossing) {
+				t.Errorf("expected: %v, got: %v", crossing, got[0])
+			}
+		})
+	}
+}
+
+func TestWebhookWatchdog(t *testing.T) {
+	received := make(chan hops.Crossing, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var c hops.Crossing
+		json.NewDecoder(r.Body).Decode(&c)
+		received <- c
+	}))
+	defer srv.Close()
+
+	start := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+	c := hops.NewManualCounter(2, time.Second, start)
+	h := hops.NewWebhook(srv.URL)
+	w := hops.NewWatchdog(c, 1, 1, func(hops.Snapshot) {})
+	w.LogTo(h, "heartbeats")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	go w.Run(ctx)
+
+	// Keep ticking an idle counter until the watchdog trips
+	now := start
+	for {
+		select {
+		case got := <-received:
+			h.Wait()
+			if got.Counter != "heartbeats" || got.Value != 0 || got.Threshold != 1 {
+				t.Errorf("unexpected crossing: %+v", got)
+			}
+			return
+		case <-ctx.Done():
+			t.Fatal("expected the watchdog to alert through the webhook")
+		case <-time.After(10 * time.Millisecond):
+			now = now.Add(time.Second)
+			c.Tick(now)
+		}
+	}
+}